@@ -0,0 +1,228 @@
+// Package changes compares two crawl results of the same page.
+package changes
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"url_analyzer/backend/crawler"
+	"url_analyzer/backend/models"
+)
+
+// volatileHeaders change between responses without the page changing and
+// are left out of header comparisons.
+var volatileHeaders = map[string]bool{
+	"Age":            true,
+	"Cf-Ray":         true,
+	"Content-Length": true,
+	"Date":           true,
+	"Etag":           true,
+	"Expires":        true,
+	"Last-Modified":  true,
+	"Server-Timing":  true,
+	"Set-Cookie":     true,
+	"Via":            true,
+	"X-Amz-Cf-Id":    true,
+	"X-Cache":        true,
+	"X-Cache-Hits":   true,
+	"X-Request-Id":   true,
+	"X-Runtime":      true,
+	"X-Served-By":    true,
+	"X-Timer":        true,
+}
+
+// Report describes what changed between two crawl results of a page.
+type Report struct {
+	PageURLID uint           `json:"page_url_id"`
+	URL       string         `json:"url"`
+	From      Snapshot       `json:"from"`
+	To        Snapshot       `json:"to"`
+	Changed   bool           `json:"changed"`
+	Title     *ValueChange   `json:"title"` // nil when unchanged
+	Headings  ListChange     `json:"headings"`
+	Links     LinkChanges    `json:"links"`
+	Headers   []HeaderChange `json:"headers"`
+	Content   TextDiff       `json:"content"`
+}
+
+// Snapshot identifies one of the compared crawl results.
+type Snapshot struct {
+	ResultID  uint      `json:"result_id"`
+	FinalURL  string    `json:"final_url"`
+	CrawledAt time.Time `json:"crawled_at"`
+}
+
+// ValueChange holds the old and new value of a changed field.
+type ValueChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// ListChange lists the entries added to and removed from a list.
+type ListChange struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+// LinkChanges lists link URLs that appeared, disappeared, started failing
+// or were fixed.
+type LinkChanges struct {
+	Added       []string `json:"added"`
+	Removed     []string `json:"removed"`
+	NewlyBroken []string `json:"newly_broken"` // broken now but not before, including new links
+	Fixed       []string `json:"fixed"`        // broken before and working now
+}
+
+// HeaderChange describes a response header that was added, removed or
+// changed. From is empty for added headers and To for removed ones.
+type HeaderChange struct {
+	Name string `json:"name"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Compare reports the changes from one crawl result to another. Both results
+// must have their links loaded.
+func Compare(pageURL *models.PageURL, from, to *models.CrawlResult) *Report {
+	report := &Report{
+		PageURLID: pageURL.ID,
+		URL:       pageURL.URL,
+		From:      Snapshot{ResultID: from.ID, FinalURL: from.FinalURL, CrawledAt: from.CreatedAt},
+		To:        Snapshot{ResultID: to.ID, FinalURL: to.FinalURL, CrawledAt: to.CreatedAt},
+		Headings:  compareLists(headingLines(from.Outline), headingLines(to.Outline)),
+		Links:     compareLinks(from.Links, to.Links),
+		Headers:   compareHeaders(from.ResponseHeaders, to.ResponseHeaders),
+		Content:   diffText(from.Text, to.Text),
+	}
+	if from.Title != to.Title {
+		report.Title = &ValueChange{From: from.Title, To: to.Title}
+	}
+
+	report.Changed = report.Title != nil ||
+		len(report.Headings.Added)+len(report.Headings.Removed) > 0 ||
+		len(report.Links.Added)+len(report.Links.Removed)+len(report.Links.NewlyBroken)+len(report.Links.Fixed) > 0 ||
+		len(report.Headers) > 0 ||
+		report.Content.Added+report.Content.Removed > 0
+	return report
+}
+
+// headingLines flattens a heading outline into "h2: Text" lines in document order.
+func headingLines(outline *crawler.HeadingOutline) []string {
+	if outline == nil {
+		return nil
+	}
+	var lines []string
+	var walk func([]*crawler.Heading)
+	walk = func(headings []*crawler.Heading) {
+		for _, h := range headings {
+			lines = append(lines, fmt.Sprintf("h%d: %s", h.Level, h.Text))
+			walk(h.Children)
+		}
+	}
+	walk(outline.Headings)
+	return lines
+}
+
+// compareLists returns the entries of b missing from a and the entries of a
+// missing from b, counting repeated entries.
+func compareLists(a, b []string) ListChange {
+	change := ListChange{Added: []string{}, Removed: []string{}}
+	counts := make(map[string]int, len(a))
+	for _, s := range a {
+		counts[s]++
+	}
+	for _, s := range b {
+		if counts[s] > 0 {
+			counts[s]--
+		} else {
+			change.Added = append(change.Added, s)
+		}
+	}
+	for _, s := range a {
+		if counts[s] > 0 {
+			counts[s]--
+			change.Removed = append(change.Removed, s)
+		}
+	}
+	return change
+}
+
+// compareLinks compares the link targets of two results. A target counts as
+// broken when any link to it is broken.
+func compareLinks(from, to []models.CrawlLink) LinkChanges {
+	before, after := linkTargets(from), linkTargets(to)
+	changes := LinkChanges{Added: []string{}, Removed: []string{}, NewlyBroken: []string{}, Fixed: []string{}}
+	for _, target := range sortedKeys(after) {
+		wasBroken, existed := before[target]
+		if !existed {
+			changes.Added = append(changes.Added, target)
+		}
+		if after[target] && !wasBroken {
+			changes.NewlyBroken = append(changes.NewlyBroken, target)
+		}
+		if existed && wasBroken && !after[target] {
+			changes.Fixed = append(changes.Fixed, target)
+		}
+	}
+	for _, target := range sortedKeys(before) {
+		if _, ok := after[target]; !ok {
+			changes.Removed = append(changes.Removed, target)
+		}
+	}
+	return changes
+}
+
+// linkTargets maps the resolved target of every link to whether it is broken,
+// falling back to the raw href for links that did not resolve.
+func linkTargets(links []models.CrawlLink) map[string]bool {
+	targets := make(map[string]bool, len(links))
+	for _, link := range links {
+		target := link.URL
+		if target == "" {
+			target = link.Href
+		}
+		targets[target] = targets[target] || link.Broken
+	}
+	return targets
+}
+
+// compareHeaders lists the response headers that differ, ignoring volatile headers.
+func compareHeaders(from, to map[string]string) []HeaderChange {
+	changes := []HeaderChange{}
+	names := make(map[string]bool, len(from)+len(to))
+	for name := range from {
+		names[name] = true
+	}
+	for name := range to {
+		names[name] = true
+	}
+	for _, name := range sortedKeys(names) {
+		if volatileHeaders[name] {
+			continue
+		}
+		if from[name] != to[name] {
+			changes = append(changes, HeaderChange{Name: name, From: from[name], To: to[name]})
+		}
+	}
+	return changes
+}
+
+// sortedKeys returns the keys of a map in ascending order.
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// splitLines splits page text into lines, returning no lines for empty text.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
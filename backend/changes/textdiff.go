@@ -0,0 +1,142 @@
+package changes
+
+// Limits of the content diff.
+const (
+	maxDiffLines    = 500  // changed lines included in a report
+	maxEditDistance = 2000 // edits searched for before the texts are treated as unrelated
+)
+
+// Diff line operations.
+const (
+	OpAdded   = "+"
+	OpRemoved = "-"
+)
+
+// TextDiff lists the lines added to and removed from the page text.
+type TextDiff struct {
+	Added     int        `json:"added"`   // number of added lines
+	Removed   int        `json:"removed"` // number of removed lines
+	Lines     []DiffLine `json:"lines"`   // changed lines in text order
+	Truncated bool       `json:"truncated"`
+}
+
+// DiffLine is a changed line of text.
+type DiffLine struct {
+	Op   string `json:"op"` // "+" for added, "-" for removed
+	Text string `json:"text"`
+}
+
+// diffText returns the line diff between two page texts.
+func diffText(from, to string) TextDiff {
+	diff := TextDiff{Lines: []DiffLine{}}
+	for _, line := range diffLines(splitLines(from), splitLines(to)) {
+		if line.Op == OpAdded {
+			diff.Added++
+		} else {
+			diff.Removed++
+		}
+		if len(diff.Lines) < maxDiffLines {
+			diff.Lines = append(diff.Lines, line)
+		} else {
+			diff.Truncated = true
+		}
+	}
+	return diff
+}
+
+// diffLines returns the changed lines of a shortest edit script from a to b.
+// The common prefix and suffix are skipped before searching, and texts that
+// need more than maxEditDistance edits are reported as entirely replaced.
+func diffLines(a, b []string) []DiffLine {
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		a, b = a[1:], b[1:]
+	}
+	for len(a) > 0 && len(b) > 0 && a[len(a)-1] == b[len(b)-1] {
+		a, b = a[:len(a)-1], b[:len(b)-1]
+	}
+
+	if lines, ok := myers(a, b); ok {
+		return lines
+	}
+	lines := make([]DiffLine, 0, len(a)+len(b))
+	for _, s := range a {
+		lines = append(lines, DiffLine{Op: OpRemoved, Text: s})
+	}
+	for _, s := range b {
+		lines = append(lines, DiffLine{Op: OpAdded, Text: s})
+	}
+	return lines
+}
+
+// myers implements Myers' O(ND) difference algorithm. It keeps the furthest
+// reaching paths of every edit distance to walk the edit script back, and
+// gives up once the distance exceeds maxEditDistance.
+func myers(a, b []string) ([]DiffLine, bool) {
+	n, m := len(a), len(b)
+	limit := n + m
+	if limit > maxEditDistance {
+		limit = maxEditDistance
+	}
+
+	// v[offset+k] is the furthest x reached on diagonal k = x - y
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	var trace [][]int
+	for d := 0; d <= limit; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // insertion from diagonal k+1
+			} else {
+				x = v[offset+k-1] + 1 // deletion from diagonal k-1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+				return backtrack(a, b, trace), true
+			}
+		}
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+	}
+	return nil, false
+}
+
+// backtrack walks the furthest reaching paths from the end of both texts back
+// to the start, collecting the changed lines.
+func backtrack(a, b []string, trace [][]int) []DiffLine {
+	var reversed []DiffLine
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1] // diagonals -(d-1)..d-1 at index k+d-1
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && prev[k-1+d-1] < prev[k+1+d-1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := prev[prevK+d-1]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+		}
+		if x == prevX {
+			reversed = append(reversed, DiffLine{Op: OpAdded, Text: b[prevY]})
+		} else {
+			reversed = append(reversed, DiffLine{Op: OpRemoved, Text: a[prevX]})
+		}
+		x, y = prevX, prevY
+	}
+
+	lines := make([]DiffLine, len(reversed))
+	for i, line := range reversed {
+		lines[len(reversed)-1-i] = line
+	}
+	return lines
+}
@@ -6,10 +6,12 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
 	"url_analyzer/backend/auth"
+	"url_analyzer/backend/crawler"
 	"url_analyzer/backend/handlers"
 	"url_analyzer/backend/models"
 	"url_analyzer/backend/repository"
@@ -28,6 +30,7 @@ type Config struct {
 	DatabaseDSN        string
 	ServerAddress      string
 	WorkerPollInterval time.Duration
+	Crawler            *crawler.Config
 }
 
 // loadConfig loads configuration from environment variables.
@@ -55,10 +58,35 @@ func loadConfig() (*Config, error) {
 		pollInterval = 5 * time.Second
 	}
 
+	crawlerCfg := crawler.DefaultConfig()
+	if hops, err := strconv.Atoi(os.Getenv("REDIRECT_WARN_HOPS")); err == nil && hops >= 0 {
+		crawlerCfg.RedirectWarnHops = hops
+	}
+	if days, err := strconv.Atoi(os.Getenv("CERT_EXPIRY_WARN_DAYS")); err == nil && days >= 0 {
+		crawlerCfg.CertExpiryWarnDays = days
+	}
+	if mode := os.Getenv("FETCH_MODE"); mode != "" {
+		switch mode {
+		case crawler.FetchModeLive, crawler.FetchModeRecord, crawler.FetchModeReplay:
+			crawlerCfg.FetchMode = mode
+		default:
+			return nil, fmt.Errorf("invalid FETCH_MODE %q (want live, record or replay)", mode)
+		}
+	}
+	if dir := os.Getenv("FETCH_DIR"); dir != "" {
+		crawlerCfg.FetchDir = dir
+	}
+	if size, err := strconv.ParseInt(os.Getenv("MAX_BODY_SIZE"), 10, 64); err == nil && size > 0 {
+		crawlerCfg.MaxBodySize = size
+	}
+	crawlerCfg.ArchiveDir = os.Getenv("ARCHIVE_DIR")
+	crawlerCfg.ArchiveLinks = os.Getenv("ARCHIVE_LINKS") == "true"
+
 	return &Config{
 		DatabaseDSN:        dsn,
 		ServerAddress:      addr,
 		WorkerPollInterval: pollInterval,
+		Crawler:            crawlerCfg,
 	}, nil
 }
 
@@ -89,14 +117,14 @@ func main() {
 	}
 
 	// Migrate models
-	if err := db.WithContext(ctx).AutoMigrate(&models.User{}, &models.TokenPair{}, &models.CrawlRequest{}, &models.CrawlResult{}); err != nil {
+	if err := db.WithContext(ctx).AutoMigrate(&models.User{}, &models.TokenPair{}, &models.CrawlRequest{}, &models.PageURL{}, &models.CrawlResult{}, &models.CrawlLink{}); err != nil {
 		log.Fatal().Err(err).Msg("Failed to migrate database models")
 	}
 
 	// Initialize services
 	authService := auth.NewAuthService()
 	repo := repository.NewDBRepository(db)
-	workerCfg := &worker.Config{PollInterval: cfg.WorkerPollInterval}
+	workerCfg := &worker.Config{PollInterval: cfg.WorkerPollInterval, Crawler: cfg.Crawler}
 	workerInstance := worker.NewWorker(repo, workerCfg)
 
 	// Start worker
@@ -105,7 +133,7 @@ func main() {
 	}
 
 	// Initialize handlers
-	mainHandler := handlers.NewHandler(repo)
+	mainHandler := handlers.NewHandler(repo, crawler.NewCrawler(cfg.Crawler))
 	authHandler := handlers.NewAuthHandler(db, authService)
 
 	// Create router
@@ -131,7 +159,13 @@ func main() {
 	protected.Use(auth.JWTMiddleware(authService))
 	{
 		protected.POST("/crawl", mainHandler.SubmitURL)
+		protected.POST("/sitemaps", mainHandler.ImportSitemap)
 		protected.GET("/results", mainHandler.GetResults)
+		protected.GET("/results/:id/links", mainHandler.GetResultLinks)
+		protected.GET("/results/:id/warc", mainHandler.GetResultArchive)
+		protected.GET("/urls", mainHandler.GetURLs)
+		protected.GET("/urls/:id/diff", mainHandler.GetURLDiff)
+		protected.GET("/duplicates", mainHandler.GetDuplicates)
 	}
 
 	// Start server in a goroutine
@@ -0,0 +1,214 @@
+package crawler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Accessibility finding codes.
+const (
+	A11yImageMissingAlt   = "image_missing_alt"
+	A11yInputMissingLabel = "input_missing_label"
+	A11yMissingLang       = "missing_html_lang"
+	A11ySkippedHeading    = "skipped_heading_level"
+	A11yEmptyLink         = "empty_link"
+	A11yEmptyButton       = "empty_button"
+	A11yDuplicateID       = "duplicate_id"
+	A11yMissingMain       = "missing_main_landmark"
+	A11yMissingNav        = "missing_navigation_landmark"
+)
+
+// unlabeledInputTypes are input types that need no label.
+var unlabeledInputTypes = map[string]bool{
+	"hidden": true,
+	"submit": true,
+	"reset":  true,
+	"button": true,
+	"image":  true,
+}
+
+// analyzeAccessibility walks the document and reports WCAG-oriented issues.
+// Skipped heading levels are taken from the document's heading outline.
+func analyzeAccessibility(doc *html.Node, outline *HeadingOutline) []Finding {
+	var findings []Finding
+	add := func(code, severity, message string, n *html.Node) {
+		f := Finding{Code: code, Severity: severity, Message: message}
+		if n != nil {
+			f.Path = elementPath(n)
+		}
+		findings = append(findings, f)
+	}
+
+	labelFor := make(map[string]bool)
+	ids := make(map[string]int)
+	var inputs []*html.Node
+	hasMain, hasNav := false, false
+
+	var traverse func(*html.Node)
+	traverse = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if id := getAttr(n, "id"); id != "" {
+				ids[id]++
+				if ids[id] == 2 {
+					add(A11yDuplicateID, SeverityError, fmt.Sprintf("id %q is used more than once", id), n)
+				}
+			}
+
+			switch role := strings.ToLower(getAttr(n, "role")); {
+			case n.Data == "main" || role == "main":
+				hasMain = true
+			case n.Data == "nav" || role == "navigation":
+				hasNav = true
+			}
+
+			switch n.Data {
+			case "html":
+				if strings.TrimSpace(getAttr(n, "lang")) == "" {
+					add(A11yMissingLang, SeverityError, "<html> element has no lang attribute", n)
+				}
+			case "img":
+				if _, ok := attrValue(n, "alt"); !ok && !isPresentational(n) {
+					add(A11yImageMissingAlt, SeverityError, "image has no alt attribute", n)
+				}
+			case "label":
+				if target := getAttr(n, "for"); target != "" {
+					labelFor[target] = true
+				}
+			case "input", "select", "textarea":
+				inputs = append(inputs, n)
+			case "a":
+				if _, ok := attrValue(n, "href"); ok && accessibleName(n) == "" {
+					add(A11yEmptyLink, SeverityError, "link has no accessible name", n)
+				}
+			case "button":
+				if accessibleName(n) == "" {
+					add(A11yEmptyButton, SeverityError, "button has no accessible name", n)
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			traverse(c)
+		}
+	}
+	traverse(doc)
+
+	// Heading order is checked once, by the outline
+	for _, f := range outline.Findings {
+		if f.Code == OutlineLevelJump {
+			f.Code = A11ySkippedHeading
+			findings = append(findings, f)
+		}
+	}
+
+	// Labels may come after their inputs, so inputs are checked once the whole tree is known
+	for _, n := range inputs {
+		if n.Data == "input" && unlabeledInputTypes[strings.ToLower(getAttr(n, "type"))] {
+			continue
+		}
+		if hasLabel(n, labelFor) {
+			continue
+		}
+		add(A11yInputMissingLabel, SeverityError, fmt.Sprintf("<%s> has no associated label", n.Data), n)
+	}
+
+	if !hasMain {
+		add(A11yMissingMain, SeverityWarning, "page has no <main> landmark", nil)
+	}
+	if !hasNav {
+		add(A11yMissingNav, SeverityInfo, "page has no <nav> landmark", nil)
+	}
+	return findings
+}
+
+// hasLabel reports whether a form control has an associated label.
+func hasLabel(n *html.Node, labelFor map[string]bool) bool {
+	if id := getAttr(n, "id"); id != "" && labelFor[id] {
+		return true
+	}
+	for _, key := range []string{"aria-label", "aria-labelledby", "title"} {
+		if strings.TrimSpace(getAttr(n, key)) != "" {
+			return true
+		}
+	}
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode && p.Data == "label" {
+			return true
+		}
+	}
+	return false
+}
+
+// accessibleName approximates the accessible name of a link or button from
+// its ARIA attributes, text content, image alt text and title.
+func accessibleName(n *html.Node) string {
+	for _, key := range []string{"aria-label", "aria-labelledby"} {
+		if v := strings.TrimSpace(getAttr(n, key)); v != "" {
+			return v
+		}
+	}
+	if name := anchorText(n); name != "" {
+		return name
+	}
+	if n.Data == "button" || n.Data == "input" {
+		if v := strings.TrimSpace(getAttr(n, "value")); v != "" {
+			return v
+		}
+	}
+	return strings.TrimSpace(getAttr(n, "title"))
+}
+
+// isPresentational reports whether an element is hidden from assistive technology.
+func isPresentational(n *html.Node) bool {
+	role := strings.ToLower(getAttr(n, "role"))
+	return role == "presentation" || role == "none" || getAttr(n, "aria-hidden") == "true"
+}
+
+// attrValue returns the value of the named attribute and whether it is present.
+func attrValue(n *html.Node, key string) (string, bool) {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
+
+// elementPath returns a CSS-like path identifying an element, such as
+// "html > body > div:nth-of-type(2) > img#logo".
+func elementPath(n *html.Node) string {
+	var parts []string
+	for ; n != nil && n.Type == html.ElementNode; n = n.Parent {
+		part := n.Data
+		if id := getAttr(n, "id"); id != "" {
+			part += "#" + id
+		} else if index, total := typeIndex(n); total > 1 {
+			part += ":nth-of-type(" + strconv.Itoa(index) + ")"
+		}
+		parts = append(parts, part)
+	}
+	for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+		parts[i], parts[j] = parts[j], parts[i]
+	}
+	return strings.Join(parts, " > ")
+}
+
+// typeIndex returns the 1-based position of an element among its siblings of
+// the same tag, and the number of such siblings.
+func typeIndex(n *html.Node) (int, int) {
+	if n.Parent == nil {
+		return 1, 1
+	}
+	index, total := 0, 0
+	for c := n.Parent.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.Data == n.Data {
+			total++
+			if c == n {
+				index = total
+			}
+		}
+	}
+	return index, total
+}
@@ -0,0 +1,124 @@
+package crawler
+
+import (
+	"bytes"
+	"mime"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+// Sources of a page's character encoding, in order of precedence.
+const (
+	EncodingSourceBOM      = "bom"
+	EncodingSourceHeader   = "header"
+	EncodingSourceMeta     = "meta"
+	EncodingSourceDetected = "detected"
+)
+
+// metaPrescanBytes is how far into the document a <meta charset> is looked for.
+const metaPrescanBytes = 1024
+
+// byteOrderMarks maps byte order marks to the encoding they announce.
+var byteOrderMarks = []struct {
+	bom      []byte
+	encoding string
+}{
+	{[]byte{0xEF, 0xBB, 0xBF}, "utf-8"},
+	{[]byte{0xFE, 0xFF}, "utf-16be"},
+	{[]byte{0xFF, 0xFE}, "utf-16le"},
+}
+
+// EncodingInfo describes how the character encoding of a page was determined.
+type EncodingInfo struct {
+	Encoding      string `json:"encoding"` // canonical name of the encoding used to decode the page
+	Source        string `json:"source"`   // bom, header, meta or detected
+	HeaderCharset string `json:"header_charset,omitempty"`
+	MetaCharset   string `json:"meta_charset,omitempty"`
+	Mismatch      bool   `json:"mismatch"` // header and <meta> declare different encodings
+}
+
+// decodeBody transcodes a page to UTF-8, determining its encoding from the
+// byte order mark, the Content-Type header, a <meta> declaration in the first
+// kilobyte or, failing those, whether the body is valid UTF-8.
+func decodeBody(body []byte, contentType string) ([]byte, *EncodingInfo) {
+	info := &EncodingInfo{MetaCharset: prescanMetaCharset(body)}
+	if _, params, err := mime.ParseMediaType(contentType); err == nil {
+		info.HeaderCharset = params["charset"]
+	}
+
+	headerEnc, headerName := charset.Lookup(info.HeaderCharset)
+	metaEnc, metaName := charset.Lookup(info.MetaCharset)
+	info.Mismatch = headerEnc != nil && metaEnc != nil && headerName != metaName
+
+	bom, rest := splitBOM(body)
+	enc, name := headerEnc, headerName
+	info.Source = EncodingSourceHeader
+	switch {
+	case bom != "":
+		enc, name = charset.Lookup(bom)
+		info.Source = EncodingSourceBOM
+		body = rest
+	case headerEnc != nil:
+		// The Content-Type charset selected above
+	case metaEnc != nil:
+		enc, name = metaEnc, metaName
+		info.Source = EncodingSourceMeta
+	case utf8.Valid(body):
+		enc, name = charset.Lookup("utf-8")
+		info.Source = EncodingSourceDetected
+	default:
+		enc, name = charset.Lookup("windows-1252")
+		info.Source = EncodingSourceDetected
+	}
+	info.Encoding = name
+
+	decoded, err := enc.NewDecoder().Bytes(body)
+	if err != nil {
+		// Leave the body as is; the HTML parser copes with invalid UTF-8
+		return body, info
+	}
+	return decoded, info
+}
+
+// splitBOM returns the encoding announced by a byte order mark, if any, and
+// the body without it.
+func splitBOM(body []byte) (string, []byte) {
+	for _, b := range byteOrderMarks {
+		if bytes.HasPrefix(body, b.bom) {
+			return b.encoding, body[len(b.bom):]
+		}
+	}
+	return "", body
+}
+
+// prescanMetaCharset returns the charset declared by a <meta charset> or
+// <meta http-equiv="Content-Type"> tag in the first kilobyte of the body.
+func prescanMetaCharset(body []byte) string {
+	if len(body) > metaPrescanBytes {
+		body = body[:metaPrescanBytes]
+	}
+	z := html.NewTokenizer(bytes.NewReader(body))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return ""
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			if tok.Data != "meta" {
+				continue
+			}
+			n := &html.Node{Attr: tok.Attr}
+			if cs := strings.TrimSpace(getAttr(n, "charset")); cs != "" {
+				return cs
+			}
+			if strings.EqualFold(getAttr(n, "http-equiv"), "content-type") {
+				if _, params, err := mime.ParseMediaType(getAttr(n, "content")); err == nil && params["charset"] != "" {
+					return params["charset"]
+				}
+			}
+		}
+	}
+}
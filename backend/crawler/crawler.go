@@ -2,6 +2,7 @@ package crawler
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -15,6 +16,12 @@ import (
 // CrawlData represents the result of a web crawling operation.
 type CrawlData struct {
 	URL            string  `json:"url"`
+	FinalURL       string  `json:"final_url"`
+	StatusCode     int     `json:"status_code"` // status of the final response; only 200 responses are analyzed
+	MediaType      string  `json:"media_type"`
+	Size           int64   `json:"size"`          // body size in bytes
+	Truncated      bool    `json:"truncated"`     // only the first MaxBodySize bytes were analyzed
+	ResourceType   string  `json:"resource_type"` // html, pdf, image, json or other
 	HTMLVersion    string  `json:"html_version"`
 	Title          string  `json:"title"`
 	H1Count        int     `json:"h1_count"`
@@ -28,61 +35,294 @@ type CrawlData struct {
 	BrokenLinks    int     `json:"broken_links"`
 	HasLoginForm   bool    `json:"has_login_form"`
 	ProcessingTime float64 `json:"processing_time"`
+
+	// RobotsSkippedLinks lists links that were not checked because robots.txt disallows them.
+	RobotsSkippedLinks []string `json:"robots_skipped_links"`
+
+	// RedirectChain holds the redirects followed from URL to FinalURL.
+	RedirectChain        []RedirectHop `json:"redirect_chain"`
+	RedirectChainTooLong bool          `json:"redirect_chain_too_long"`
+	RedirectLoop         bool          `json:"redirect_loop"`
+
+	// Resource holds the analysis of non-HTML targets, which are not parsed as HTML.
+	Resource *ResourceInfo `json:"resource"`
+
+	// ArchiveRecordID is the WARC-Record-ID of the archived page response.
+	ArchiveRecordID string `json:"archive_record_id,omitempty"`
+
+	// Encoding describes the detected character encoding of the page.
+	Encoding *EncodingInfo `json:"encoding"`
+
+	// Links holds the details of every link on the page.
+	Links []LinkDetail `json:"links"`
+
+	// SEO holds the page's search engine metadata and findings.
+	SEO *SEOData `json:"seo"`
+
+	// Forms describes every form on the page.
+	Forms []FormInfo `json:"forms"`
+
+	// AccessibilityFindings lists WCAG-oriented issues found on the page.
+	AccessibilityFindings []Finding `json:"accessibility_findings"`
+
+	// Outline is the nested h1-h6 heading structure of the page.
+	Outline *HeadingOutline `json:"outline"`
+
+	// StructuredData holds JSON-LD, microdata and RDFa entities and validation findings.
+	StructuredData *StructuredData `json:"structured_data"`
+
+	// Resources lists the page's subresources and summarizes its weight.
+	Resources *ResourceInventory `json:"resources"`
+
+	// TLS describes the TLS connection and certificate chain of HTTPS pages.
+	TLS *TLSInfo `json:"tls"`
+
+	// MixedContent lists the http:// subresources of HTTPS pages.
+	MixedContent []MixedContent `json:"mixed_content"`
+
+	// SecurityHeaders holds the graded security headers of the page response.
+	SecurityHeaders *SecurityHeaders `json:"security_headers"`
+
+	// ResponseHeaders holds the headers of the final response, with repeated
+	// headers joined by commas.
+	ResponseHeaders map[string]string `json:"response_headers"`
+
+	// Text is the visible text of the page, one line per block element, kept
+	// for comparing crawls of the same URL.
+	Text string `json:"-"`
+
+	// MainText is the text of the page's main content, without navigation
+	// and other boilerplate, and Readability holds its statistics.
+	MainText    string       `json:"-"`
+	Readability *Readability `json:"readability"`
+
+	// SimHash fingerprints the main text for near-duplicate detection.
+	SimHash string `json:"simhash"`
+
+	// Language holds the detected language of the page text and flags
+	// declared languages that differ from it.
+	Language *LanguageInfo `json:"language"`
+
+	// InternalURLs holds the absolute URLs of internal links found on the page,
+	// used by site crawls to discover the next pages to visit.
+	InternalURLs []string `json:"-"`
+}
+
+// Options holds per-request crawl settings.
+type Options struct {
+	IgnoreRobots       bool // Skip robots.txt checks, for sites the user owns
+	CheckResourceSizes bool // Request subresources with HEAD to record their sizes
+	ArchiveID          uint // Append fetched responses to the WARC file of this crawl request
+
+	// Request settings for the page request, and for link checks when
+	// ApplyToLinks is set. Headers and cookies are only sent to the page's
+	// host and its subdomains. Robots.txt and subresources are always
+	// requested with the defaults.
+	UserAgent      string            // Overrides UserAgent
+	Header         map[string]string // Extra request headers by name
+	Cookies        map[string]string // Cookies by name
+	AcceptLanguage string            // Accept-Language header
+	Timeout        time.Duration     // Overrides RequestTimeout
+	ApplyToLinks   bool              // Also use the settings above for link checks
+}
+
+// Config holds crawler configuration settings.
+type Config struct {
+	RequestTimeout     time.Duration // Timeout for a single HTTP request
+	LinkCheckTimeout   time.Duration // Deadline for checking all links of one page
+	LinkCheckWorkers   int           // Number of links checked concurrently
+	LinkCheckPerHost   int           // Number of concurrent checks against a single host
+	RedirectWarnHops   int           // Redirect chains with more hops are flagged as too long
+	CertExpiryWarnDays int           // Certificates expiring within this many days are flagged
+	FetchMode          string        // live (default), record or replay
+	FetchDir           string        // Directory holding recorded responses in record and replay mode
+	Fetcher            Fetcher       // Overrides FetchMode when set, for example in tests
+	ArchiveDir         string        // Directory of WARC files; archiving is disabled when empty
+	ArchiveLinks       bool          // Also archive link check exchanges
+	MaxBodySize        int64         // Page bodies are read up to this many bytes; longer pages are analyzed truncated
+}
+
+// DefaultConfig returns the default crawler configuration.
+func DefaultConfig() *Config {
+	return &Config{
+		RequestTimeout:     10 * time.Second,
+		LinkCheckTimeout:   60 * time.Second,
+		LinkCheckWorkers:   16,
+		LinkCheckPerHost:   4,
+		RedirectWarnHops:   3,
+		CertExpiryWarnDays: 30,
+		FetchMode:          FetchModeLive,
+		FetchDir:           "recordings",
+		MaxBodySize:        10 << 20, // 10 MiB
+	}
 }
 
 // Crawler performs web crawling operations.
 type Crawler struct {
-	client *http.Client
+	fetcher    Fetcher
+	header     http.Header // sent with every request, may override the User-Agent
+	siteHeader http.Header // sent only to siteHost and its subdomains
+	siteHost   string
+	robots     *robotsCache
+	cfg        *Config
 }
 
-// NewCrawler creates a new Crawler instance.
-func NewCrawler() *Crawler {
-	return &Crawler{
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+// NewCrawler creates a new Crawler with the provided configuration, using the
+// defaults when cfg is nil.
+func NewCrawler(cfg *Config) *Crawler {
+	if cfg == nil {
+		cfg = DefaultConfig()
 	}
+	// Redirects are followed by fetch so that every hop can be recorded
+	c := &Crawler{
+		fetcher: newFetcher(cfg),
+		cfg:     cfg,
+	}
+	c.robots = newRobotsCache(c.do)
+	return c
 }
 
-// Crawl fetches and analyzes a webpage, returning crawl data.
-func (c *Crawler) Crawl(targetURL string) (*CrawlData, error) {
+// withFetcher returns a copy of the crawler sending requests through f.
+func (c *Crawler) withFetcher(f Fetcher) *Crawler {
+	cc := *c
+	cc.fetcher = f
+	return &cc
+}
+
+// Crawl fetches and analyzes a webpage, returning crawl data. It returns once
+// all links have been checked or the link check deadline has passed.
+func (c *Crawler) Crawl(ctx context.Context, targetURL string, opts Options) (*CrawlData, error) {
 	startTime := time.Now()
 
 	// Validate URL
-	if _, err := url.ParseRequestURI(targetURL); err != nil {
+	target, err := url.ParseRequestURI(targetURL)
+	if err != nil {
 		return nil, fmt.Errorf("invalid URL: %w", err)
 	}
 
-	// Fetch webpage
-	resp, err := c.client.Get(targetURL)
+	// Honor robots.txt unless the request overrides it
+	if !opts.IgnoreRobots {
+		if !c.robots.allowed(ctx, targetURL) {
+			return nil, fmt.Errorf("failed to fetch URL %s: %w", targetURL, ErrDisallowedByRobots)
+		}
+		if err := c.robots.wait(ctx, targetURL); err != nil {
+			return nil, fmt.Errorf("failed to fetch URL %s: %w", targetURL, err)
+		}
+	}
+
+	// Send the page request, and optionally link checks, with the request settings
+	pageFetcher, linkChecker := c, c
+	if opts.hasRequestSettings() {
+		pageFetcher = c.withRequestSettings(opts, target.Hostname())
+		if opts.ApplyToLinks {
+			linkChecker = pageFetcher
+		}
+	}
+
+	// Archive the page exchange, and optionally link checks, in the request's WARC file
+	var archive *archivingFetcher
+	if opts.ArchiveID != 0 && c.cfg.ArchiveDir != "" {
+		warc, err := openWARC(c.ArchivePath(opts.ArchiveID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open archive for URL %s: %w", targetURL, err)
+		}
+		defer warc.Close()
+		archive = &archivingFetcher{next: pageFetcher.fetcher, warc: warc, limit: maxSavedBody, ids: make(map[string]string)}
+		if c.cfg.ArchiveLinks {
+			linkChecker = linkChecker.withFetcher(&archivingFetcher{
+				next:  linkChecker.fetcher,
+				warc:  warc,
+				limit: maxSavedLinkBody,
+				ids:   make(map[string]string),
+			})
+		}
+		pageFetcher = pageFetcher.withFetcher(archive)
+	}
+
+	// Fetch webpage, recording any redirects on the way
+
+	resp, chain, err := pageFetcher.fetch(ctx, http.MethodGet, targetURL, nil)
 	if err != nil {
+		switch {
+		case isCertificateError(err):
+			return c.crawlUntrustedPage(ctx, targetURL, chain, err, startTime)
+		case isRedirectError(err):
+			return c.crawlBrokenRedirect(targetURL, chain, err, startTime), nil
+		}
 		return nil, fmt.Errorf("failed to fetch URL %s: %w", targetURL, err)
 	}
 	defer resp.Body.Close()
 
+	// Other statuses only record where the URL led, without analyzing the body
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("received status code %d for URL %s", resp.StatusCode, targetURL)
+		return &CrawlData{
+			URL:                  targetURL,
+			FinalURL:             finalURL(resp, targetURL),
+			StatusCode:           resp.StatusCode,
+			RedirectChain:        chain,
+			RedirectChainTooLong: len(chain) > c.cfg.RedirectWarnHops,
+			ResponseHeaders:      flattenHeader(resp.Header),
+			ProcessingTime:       time.Since(startTime).Seconds(),
+		}, nil
 	}
 
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
+	// Read response body, up to the size limit
+	body, err := io.ReadAll(io.LimitReader(resp.Body, c.cfg.MaxBodySize+1))
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response body for URL %s: %w", targetURL, err)
 	}
+	truncated := int64(len(body)) > c.cfg.MaxBodySize
+	if truncated {
+		body = body[:c.cfg.MaxBodySize]
+	}
+
+	data := &CrawlData{
+		URL:                  targetURL,
+		FinalURL:             finalURL(resp, targetURL),
+		StatusCode:           resp.StatusCode,
+		RedirectChain:        chain,
+		RedirectChainTooLong: len(chain) > c.cfg.RedirectWarnHops,
+		MediaType:            detectMediaType(resp.Header.Get("Content-Type"), body),
+		Size:                 int64(len(body)),
+		Truncated:            truncated,
+	}
+	if truncated && resp.ContentLength > data.Size {
+		data.Size = resp.ContentLength
+	}
+	data.ResourceType = resourceType(data.MediaType)
+	if archive != nil {
+		data.ArchiveRecordID = archive.recordID(data.FinalURL)
+	}
+
+	// Inspect the TLS connection and grade security headers of the final response
+	if resp.Request != nil {
+		data.TLS = inspectTLS(resp.TLS, resp.Request.URL.Hostname(), c.cfg.CertExpiryWarnDays, time.Now())
+	}
+	https := strings.HasPrefix(data.FinalURL, "https://")
+	data.SecurityHeaders = analyzeSecurityHeaders(resp.Header, https)
+	data.ResponseHeaders = flattenHeader(resp.Header)
 
-	// Parse HTML
+	// Only HTML is parsed; other resources get an analysis of their own
+	if data.ResourceType != ResourceHTML {
+		data.Resource = analyzeResource(data.ResourceType, data.MediaType, body)
+		if data.Resource != nil && data.Resource.PDF != nil {
+			data.Title = data.Resource.PDF.Title
+		}
+		data.ProcessingTime = time.Since(startTime).Seconds()
+		return data, nil
+	}
+
+	// Transcode to UTF-8 and parse HTML
+	body, data.Encoding = decodeBody(body, resp.Header.Get("Content-Type"))
 	doc, err := html.Parse(bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse HTML for URL %s: %w", targetURL, err)
 	}
+	data.HTMLVersion = getHTMLVersion(doc)
 
-	data := &CrawlData{
-		URL:         targetURL,
-		HTMLVersion: getHTMLVersion(doc),
-	}
-
-	// Check for broken links in a separate goroutine
-	go c.checkBrokenLinks(targetURL, doc, data)
+	// Links resolve against the final URL, or <base href> when the page declares one
+	pageURL := data.FinalURL
+	baseURL := documentBaseURL(pageURL, doc)
 
 	// Traverse HTML document
 	var traverse func(*html.Node)
@@ -104,17 +344,18 @@ func (c *Crawler) Crawl(targetURL string) (*CrawlData, error) {
 			case "a":
 				for _, attr := range n.Attr {
 					if attr.Key == "href" {
-						if isInternalLink(targetURL, attr.Val) {
+						link := newLinkDetail(pageURL, baseURL, n, attr.Val)
+						data.Links = append(data.Links, link)
+						if link.Internal {
 							data.InternalLinks++
+							if abs, ok := resolveCrawlableURL(baseURL, attr.Val); ok {
+								data.InternalURLs = append(data.InternalURLs, abs)
+							}
 						} else {
 							data.ExternalLinks++
 						}
 					}
 				}
-			case "form":
-				if hasPasswordField(n) {
-					data.HasLoginForm = true
-				}
 			}
 		}
 		for c := n.FirstChild; c != nil; c = c.NextSibling {
@@ -123,35 +364,64 @@ func (c *Crawler) Crawl(targetURL string) (*CrawlData, error) {
 	}
 	traverse(doc)
 
-	// Set title
-	data.Title = getTitle(doc)
-	data.ProcessingTime = time.Since(startTime).Seconds()
-	return data, nil
-}
+	// Analyze SEO metadata
+	data.SEO = analyzeSEO(doc, pageURL, baseURL)
 
-// checkBrokenLinks checks for broken links and updates the CrawlData.
-func (c *Crawler) checkBrokenLinks(targetURL string, doc *html.Node, data *CrawlData) {
-	var links []string
-	var traverse func(*html.Node)
-	traverse = func(n *html.Node) {
-		if n.Type == html.ElementNode && n.Data == "a" {
-			for _, attr := range n.Attr {
-				if attr.Key == "href" && !isInternalLink(targetURL, attr.Val) {
-					links = append(links, attr.Val)
-				}
-			}
-		}
-		for c := n.FirstChild; c != nil; c = c.NextSibling {
-			traverse(c)
+	// Inventory forms, keeping HasLoginForm for clients that predate the inventory
+	data.Forms = analyzeForms(doc, pageURL, baseURL)
+	for _, form := range data.Forms {
+		if form.HasPassword {
+			data.HasLoginForm = true
 		}
 	}
-	traverse(doc)
 
-	for _, link := range links {
-		if resp, err := c.client.Head(link); err != nil || resp.StatusCode >= 400 {
-			data.BrokenLinks++
-		}
+	// Build heading outline
+	data.Outline = buildOutline(doc)
+
+	// Audit accessibility
+	data.AccessibilityFindings = analyzeAccessibility(doc, data.Outline)
+
+	// Extract and validate structured data
+	data.StructuredData = extractStructuredData(doc)
+
+	// Insecure subresources only count as mixed content on HTTPS pages
+	if https {
+		data.MixedContent = findMixedContent(doc, baseURL)
+	}
+
+	// Check links for broken targets, leaving out links that robots.txt disallows
+	linkChecker.checkBrokenLinks(ctx, data, opts)
+
+	// Inventory subresources and total the page weight
+	data.Resources = inventoryResources(doc, pageURL, baseURL)
+	if opts.CheckResourceSizes {
+		c.sizeResources(ctx, data.Resources, opts)
 	}
+	data.Resources.summarizeWeight(data.Size)
+
+	// Extract visible text for change detection, and the main text for readability
+	data.Text = visibleText(doc)
+	data.MainText = mainText(doc)
+	data.Readability = analyzeReadability(data.MainText, data.Text, data.Size)
+
+	// Fingerprint and detect the language of the main text, or of the whole
+	// page when it has no main text
+	contentText := data.MainText
+	if contentText == "" {
+		contentText = data.Text
+	}
+	data.SimHash = simHash(contentText)
+
+	htmlLang := ""
+	if root := findTag(doc, "html"); root != nil {
+		htmlLang = getAttr(root, "lang")
+	}
+	data.Language = analyzeLanguage(contentText, htmlLang, resp.Header.Get("Content-Language"))
+
+	// Set title
+	data.Title = getTitle(doc)
+	data.ProcessingTime = time.Since(startTime).Seconds()
+	return data, nil
 }
 
 // getTitle extracts the page title from the HTML document.
@@ -203,6 +473,28 @@ func getHTMLVersion(n *html.Node) string {
 	return "HTML5"
 }
 
+// documentBaseURL returns the URL that relative links resolve against: the
+// page's <base href> resolved against the page URL, or the page URL itself.
+func documentBaseURL(pageURL string, doc *html.Node) string {
+	baseNode := findTag(doc, "base")
+	if baseNode == nil {
+		return pageURL
+	}
+	href := strings.TrimSpace(getAttr(baseNode, "href"))
+	if href == "" {
+		return pageURL
+	}
+	page, err := url.Parse(pageURL)
+	if err != nil {
+		return pageURL
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return pageURL
+	}
+	return page.ResolveReference(ref).String()
+}
+
 // isInternalLink checks if a link is internal to the base URL.
 func isInternalLink(baseURL, link string) bool {
 	base, err := url.Parse(baseURL)
@@ -216,19 +508,21 @@ func isInternalLink(baseURL, link string) bool {
 	return parsedLink.Host == "" || parsedLink.Host == base.Host
 }
 
-// hasPasswordField checks if a form contains a password input field.
-func hasPasswordField(n *html.Node) bool {
-	if n.Type == html.ElementNode && n.Data == "input" {
-		for _, attr := range n.Attr {
-			if attr.Key == "type" && strings.ToLower(attr.Val) == "password" {
-				return true
-			}
-		}
+// resolveCrawlableURL resolves a link against the base URL and returns it
+// without its fragment, reporting false for non-HTTP(S) links.
+func resolveCrawlableURL(baseURL, link string) (string, bool) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return "", false
 	}
-	for c := n.FirstChild; c != nil; c = c.NextSibling {
-		if hasPasswordField(c) {
-			return true
-		}
+	ref, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return "", false
+	}
+	resolved := base.ResolveReference(ref)
+	if resolved.Scheme != "http" && resolved.Scheme != "https" {
+		return "", false
 	}
-	return false
+	resolved.Fragment = ""
+	return resolved.String(), true
 }
@@ -0,0 +1,199 @@
+package crawler
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+)
+
+// Fetch modes selecting the Fetcher a Crawler uses.
+const (
+	FetchModeLive   = "live"   // send requests over the network
+	FetchModeRecord = "record" // send requests and save every response to disk
+	FetchModeReplay = "replay" // serve saved responses without network access
+)
+
+// Limits of response bodies held in memory to be archived or recorded. Link
+// checks only read the start of a body, so less of theirs is kept.
+const (
+	maxSavedBody     = 10 << 20 // 10 MiB
+	maxSavedLinkBody = 64 << 10 // 64 KiB
+)
+
+// ErrNotRecorded is returned in replay mode for requests without a saved response.
+var ErrNotRecorded = errors.New("no recorded response")
+
+// Fetcher sends a single HTTP request and returns its response without
+// following redirects. *http.Client satisfies Fetcher when its CheckRedirect
+// policy returns redirect responses to the caller.
+type Fetcher interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// newFetcher returns the Fetcher selected by the configuration.
+func newFetcher(cfg *Config) Fetcher {
+	if cfg.Fetcher != nil {
+		return cfg.Fetcher
+	}
+	switch cfg.FetchMode {
+	case FetchModeRecord:
+		return &RecordingFetcher{Next: newLiveFetcher(cfg), Dir: cfg.FetchDir}
+	case FetchModeReplay:
+		return &ReplayFetcher{Dir: cfg.FetchDir}
+	}
+	return newLiveFetcher(cfg)
+}
+
+// newLiveFetcher returns an HTTP client that leaves redirects to the caller.
+func newLiveFetcher(cfg *Config) *http.Client {
+	return &http.Client{
+		Timeout:       cfg.RequestTimeout,
+		CheckRedirect: noRedirects,
+	}
+}
+
+// RecordingFetcher sends requests through Next and saves every response it
+// receives in Dir, to be served later by a ReplayFetcher. Requests that fail
+// are not recorded. Bodies are saved up to 10 MiB, or 64 KiB for ranged link
+// checks, so longer responses replay truncated.
+type RecordingFetcher struct {
+	Next Fetcher
+	Dir  string
+}
+
+// Do sends the request and records its response.
+func (f *RecordingFetcher) Do(req *http.Request) (*http.Response, error) {
+	resp, err := f.Next.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	limit := int64(maxSavedBody)
+	if req.Header.Get("Range") != "" {
+		limit = maxSavedLinkBody
+	}
+	body, truncated, err := bufferBody(resp, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body for recording: %w", err)
+	}
+
+	dump, err := dumpResponse(resp, body, truncated)
+	if err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to dump response for recording: %w", err)
+	}
+	if err := writeRecording(f.Dir, recordingKey(req), dump); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ReplayFetcher serves the responses saved by a RecordingFetcher in Dir.
+// Replayed responses carry no TLS connection state.
+type ReplayFetcher struct {
+	Dir string
+}
+
+// Do returns the recorded response for the request.
+func (f *ReplayFetcher) Do(req *http.Request) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+	dump, err := os.ReadFile(filepath.Join(f.Dir, recordingKey(req)+".http"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w for %s %s", ErrNotRecorded, req.Method, req.URL)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recorded response: %w", err)
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(dump)), req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse recorded response for %s: %w", req.URL, err)
+	}
+	return resp, nil
+}
+
+// recordingHeaders are request headers that select a variant of a page, such
+// as its language or the session of a logged-in user.
+var recordingHeaders = []string{"Accept-Language", "Cookie"}
+
+// recordingKey identifies a request by its method, URL, byte range and
+// recordingHeaders, so that HEAD requests, ranged link checks and variants
+// of the same page are recorded separately.
+func recordingKey(req *http.Request) string {
+	key := req.Method + " " + req.URL.String() + " " + req.Header.Get("Range")
+	for _, name := range recordingHeaders {
+		if value := req.Header.Get(name); value != "" {
+			key += "\n" + name + ": " + value
+		}
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// writeRecording atomically writes a response dump to dir.
+func writeRecording(dir, key string, dump []byte) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create recording directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, key+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create recording: %w", err)
+	}
+	if _, err := tmp.Write(dump); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write recording: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write recording: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, key+".http")); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to save recording: %w", err)
+	}
+	return nil
+}
+
+// bufferBody reads up to limit bytes of a response body into memory so that
+// they can be saved, and replaces resp.Body with a reader returning the whole
+// body again. truncated reports whether the body is longer than limit, in
+// which case the rest is streamed from the connection rather than buffered.
+func bufferBody(resp *http.Response, limit int64) (body []byte, truncated bool, err error) {
+	body, err = io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, false, err
+	}
+	if int64(len(body)) <= limit {
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		return body, false, nil
+	}
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+	return body[:limit], true, nil
+}
+
+// dumpResponse returns the wire format of a response with the given body. A
+// truncated body is dumped with its own length as Content-Length.
+func dumpResponse(resp *http.Response, body []byte, truncated bool) ([]byte, error) {
+	saved := *resp
+	saved.Body = io.NopCloser(bytes.NewReader(body))
+	if truncated {
+		saved.ContentLength = int64(len(body))
+		saved.TransferEncoding = nil
+	}
+	return httputil.DumpResponse(&saved, true)
+}
@@ -0,0 +1,126 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newRecordingTestServer serves a page in the language named by the request's
+// Accept-Language header, linking to a second page.
+func newRecordingTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/":
+			title := "Welcome"
+			if r.Header.Get("Accept-Language") == "de" {
+				title = "Willkommen"
+			}
+			fmt.Fprintf(w, `<html><head><title>%s</title></head><body><a href="/about">About</a></body></html>`, title)
+		case "/about":
+			fmt.Fprint(w, "<html><body>About</body></html>")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// crawlerWithFetcher returns a crawler sending requests through f.
+func crawlerWithFetcher(f Fetcher) *Crawler {
+	cfg := DefaultConfig()
+	cfg.Fetcher = f
+	return NewCrawler(cfg)
+}
+
+func TestRecordReplayRoundTrip(t *testing.T) {
+	srv := newRecordingTestServer(t)
+	dir := t.TempDir()
+	ctx := context.Background()
+	english := Options{IgnoreRobots: true}
+	german := Options{IgnoreRobots: true, AcceptLanguage: "de"}
+
+	recorder := crawlerWithFetcher(&RecordingFetcher{Next: newLiveFetcher(DefaultConfig()), Dir: dir})
+	recorded, err := recorder.Crawl(ctx, srv.URL+"/", english)
+	if err != nil {
+		t.Fatalf("recording crawl: %v", err)
+	}
+	if _, err := recorder.Crawl(ctx, srv.URL+"/", german); err != nil {
+		t.Fatalf("recording localized crawl: %v", err)
+	}
+	srv.Close()
+
+	replayer := crawlerWithFetcher(&ReplayFetcher{Dir: dir})
+	replayed, err := replayer.Crawl(ctx, srv.URL+"/", english)
+	if err != nil {
+		t.Fatalf("replaying crawl: %v", err)
+	}
+	if replayed.Title != recorded.Title || replayed.Size != recorded.Size || replayed.SimHash != recorded.SimHash {
+		t.Errorf("replayed page = %q (%d bytes, simhash %s), want %q (%d bytes, simhash %s)",
+			replayed.Title, replayed.Size, replayed.SimHash, recorded.Title, recorded.Size, recorded.SimHash)
+	}
+	if replayed.BrokenLinks != 0 || len(replayed.Links) != 1 || replayed.Links[0].StatusCode != http.StatusOK {
+		t.Errorf("replayed links = %+v, want the recorded check of /about", replayed.Links)
+	}
+	if replayed.TLS != nil {
+		t.Error("replayed page has TLS details")
+	}
+
+	localized, err := replayer.Crawl(ctx, srv.URL+"/", german)
+	if err != nil {
+		t.Fatalf("replaying localized crawl: %v", err)
+	}
+	if localized.Title != "Willkommen" {
+		t.Errorf("localized title = %q, want the recorded German variant", localized.Title)
+	}
+}
+
+func TestReplayUnrecordedRequest(t *testing.T) {
+	replayer := crawlerWithFetcher(&ReplayFetcher{Dir: t.TempDir()})
+	_, err := replayer.Crawl(context.Background(), "http://example.com/", Options{IgnoreRobots: true})
+	if !errors.Is(err, ErrNotRecorded) {
+		t.Errorf("err = %v, want ErrNotRecorded", err)
+	}
+}
+
+func TestRecordingCapsIgnoredRange(t *testing.T) {
+	const size = 1 << 20
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Ignores the Range header and sends the whole body
+		w.Write(make([]byte, size))
+	}))
+	t.Cleanup(srv.Close)
+	dir := t.TempDir()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Range", "bytes=0-0")
+	resp, err := (&RecordingFetcher{Next: newLiveFetcher(DefaultConfig()), Dir: dir}).Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil || len(body) != size {
+		t.Errorf("read %d bytes (err %v), want the whole %d byte body", len(body), err, size)
+	}
+
+	replayed, err := (&ReplayFetcher{Dir: dir}).Do(req)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	body, _ = io.ReadAll(replayed.Body)
+	replayed.Body.Close()
+	if len(body) != maxSavedLinkBody {
+		t.Errorf("replayed %d bytes, want the recording truncated to %d", len(body), maxSavedLinkBody)
+	}
+}
@@ -0,0 +1,16 @@
+package crawler
+
+// Finding severities.
+const (
+	SeverityInfo    = "info"
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+// Finding is a single issue reported by one of the page analyzers.
+type Finding struct {
+	Code     string `json:"code"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Path     string `json:"path,omitempty"` // element path, for findings tied to an element
+}
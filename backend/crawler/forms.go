@@ -0,0 +1,225 @@
+package crawler
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Form classifications.
+const (
+	FormLogin         = "login"
+	FormSignup        = "signup"
+	FormSearch        = "search"
+	FormNewsletter    = "newsletter"
+	FormPasswordReset = "password_reset"
+	FormOther         = "other"
+)
+
+// csrfTokenNames are substrings of hidden input names used for CSRF tokens,
+// such as csrf_token, _xsrf, authenticity_token or __RequestVerificationToken.
+var csrfTokenNames = []string{"csrf", "xsrf", "token", "nonce"}
+
+// searchInputNames are input names conventionally used for search queries.
+var searchInputNames = map[string]bool{
+	"q":        true,
+	"s":        true,
+	"query":    true,
+	"search":   true,
+	"keyword":  true,
+	"keywords": true,
+}
+
+// FormInput is a control of a form.
+type FormInput struct {
+	Name         string `json:"name"`
+	Type         string `json:"type"` // input type, or select/textarea
+	Autocomplete string `json:"autocomplete,omitempty"`
+}
+
+// FormInfo describes a form on the page.
+type FormInfo struct {
+	Action         string      `json:"action"` // resolved submission URL
+	Method         string      `json:"method"`
+	Inputs         []FormInput `json:"inputs"`
+	HasPassword    bool        `json:"has_password"`
+	HasCSRFToken   bool        `json:"has_csrf_token"`  // hidden input that looks like a CSRF token
+	InsecureSubmit bool        `json:"insecure_submit"` // password form submitted over plain HTTP
+	Classification string      `json:"classification"`
+	Path           string      `json:"path"`
+}
+
+// analyzeForms records every form of the document. Form actions resolve
+// against baseURL; a missing or empty action submits to pageURL.
+func analyzeForms(doc *html.Node, pageURL, baseURL string) []FormInfo {
+	var forms []FormInfo
+	var traverse func(*html.Node)
+	traverse = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "form" {
+			forms = append(forms, newFormInfo(n, pageURL, baseURL))
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			traverse(c)
+		}
+	}
+	traverse(doc)
+	return forms
+}
+
+// newFormInfo builds the description of a single form element.
+func newFormInfo(n *html.Node, pageURL, baseURL string) FormInfo {
+	form := FormInfo{
+		Action: pageURL,
+		Method: strings.ToUpper(strings.TrimSpace(getAttr(n, "method"))),
+		Path:   elementPath(n),
+	}
+	if action := strings.TrimSpace(getAttr(n, "action")); action != "" {
+		form.Action = resolveURL(baseURL, action)
+	}
+	if form.Method != "POST" && form.Method != "DIALOG" {
+		form.Method = "GET"
+	}
+
+	var controls []*html.Node
+	var collect func(*html.Node)
+	collect = func(c *html.Node) {
+		if c.Type == html.ElementNode {
+			switch c.Data {
+			case "input", "select", "textarea":
+				controls = append(controls, c)
+			}
+		}
+		for child := c.FirstChild; child != nil; child = child.NextSibling {
+			collect(child)
+		}
+	}
+	collect(n)
+
+	for _, c := range controls {
+		input := FormInput{
+			Name:         getAttr(c, "name"),
+			Type:         c.Data,
+			Autocomplete: getAttr(c, "autocomplete"),
+		}
+		if c.Data == "input" {
+			input.Type = strings.ToLower(strings.TrimSpace(getAttr(c, "type")))
+			if input.Type == "" {
+				input.Type = "text"
+			}
+		}
+		switch input.Type {
+		case "password":
+			form.HasPassword = true
+		case "hidden":
+			if isCSRFTokenName(input.Name) {
+				form.HasCSRFToken = true
+			}
+		}
+		form.Inputs = append(form.Inputs, input)
+	}
+
+	form.Classification = classifyForm(n, form.Inputs)
+	if form.HasPassword {
+		if u, err := url.Parse(form.Action); err == nil && u.Scheme == "http" {
+			form.InsecureSubmit = true
+		}
+	}
+	return form
+}
+
+// isCSRFTokenName reports whether a hidden input name looks like a CSRF token.
+func isCSRFTokenName(name string) bool {
+	name = strings.ToLower(name)
+	for _, token := range csrfTokenNames {
+		if strings.Contains(name, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// classifyForm guesses the purpose of a form from its controls and from hints
+// in its action, attributes and text.
+func classifyForm(n *html.Node, inputs []FormInput) string {
+	hints := strings.ToLower(strings.Join([]string{
+		getAttr(n, "action"), getAttr(n, "id"), getAttr(n, "name"), getAttr(n, "class"),
+		getAttr(n, "role"), getAttr(n, "aria-label"), formText(n),
+	}, " "))
+	hasHint := func(words ...string) bool {
+		for _, w := range words {
+			if strings.Contains(hints, w) {
+				return true
+			}
+		}
+		return false
+	}
+
+	passwords, newPasswords, emails := 0, 0, 0
+	search := false
+	for _, in := range inputs {
+		switch in.Type {
+		case "password":
+			passwords++
+			if strings.Contains(strings.ToLower(in.Autocomplete), "new-password") {
+				newPasswords++
+			}
+		case "email":
+			emails++
+		case "search":
+			search = true
+		}
+		if searchInputNames[strings.ToLower(in.Name)] {
+			search = true
+		}
+		if strings.Contains(strings.ToLower(in.Name), "email") && in.Type != "hidden" {
+			emails++
+		}
+	}
+
+	resetHint := hasHint("reset", "forgot", "recover", "change password", "change-password")
+	// Login forms often link to password recovery, so reset hints only count
+	// for forms setting a new password or having no password at all
+	switch {
+	case (passwords > 1 || newPasswords > 0) && resetHint:
+		return FormPasswordReset
+	case passwords > 1, newPasswords > 0, passwords > 0 && hasHint("register", "sign up", "signup", "create account", "join"):
+		return FormSignup
+	case passwords > 0:
+		return FormLogin
+	case resetHint && emails > 0:
+		return FormPasswordReset
+	case search || hasHint("search"):
+		return FormSearch
+	case emails > 0 && hasHint("subscribe", "newsletter", "mailing list"):
+		return FormNewsletter
+	}
+	return FormOther
+}
+
+// formText returns the visible labels of a form: its text plus the values of
+// submit buttons and input placeholders.
+func formText(n *html.Node) string {
+	var sb strings.Builder
+	var traverse func(*html.Node)
+	traverse = func(c *html.Node) {
+		switch {
+		case c.Type == html.TextNode:
+			sb.WriteString(c.Data)
+			sb.WriteString(" ")
+		case c.Type == html.ElementNode && c.Data == "input":
+			switch strings.ToLower(getAttr(c, "type")) {
+			case "submit", "button", "reset":
+				sb.WriteString(getAttr(c, "value"))
+				sb.WriteString(" ")
+			}
+			sb.WriteString(getAttr(c, "placeholder"))
+			sb.WriteString(" ")
+		}
+		for child := c.FirstChild; child != nil; child = child.NextSibling {
+			traverse(child)
+		}
+	}
+	traverse(n)
+	return sb.String()
+}
@@ -0,0 +1,325 @@
+package crawler
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Language finding codes.
+const (
+	LangHTMLMismatch            = "html_lang_mismatch"
+	LangContentLanguageMismatch = "content_language_mismatch"
+)
+
+// Language detection settings.
+const (
+	ngramProfileSize      = 300   // n-grams kept per profile
+	maxNgramLength        = 3     // longest n-gram, in runes
+	maxLanguageSample     = 10000 // runes of text analyzed
+	minLanguageLetters    = 20    // shorter texts are left undetermined
+	minLanguageConfidence = 0.2   // less confident detections are left undetermined
+
+	// Out-of-place distances are normalized to 0-1. Text in a supported
+	// language scores around fullFitDistance against its profile, while
+	// unsupported languages score unknownFitDistance or more against all.
+	fullFitDistance    = 0.5
+	unknownFitDistance = 0.7
+	fullFitMargin      = 0.1 // relative lead over the runner-up for full confidence
+)
+
+// languageSamples holds a sample text per language, named by ISO 639-1 code,
+// from which the n-gram profiles are built.
+//
+//go:embed langdata/*.txt
+var languageSamples embed.FS
+
+// scriptLanguages maps scripts written in only one of the supported languages
+// to that language. Latin and Cyrillic text is told apart by n-gram profiles.
+var scriptLanguages = map[string]string{
+	"Arabic":     "ar",
+	"Devanagari": "hi",
+	"Greek":      "el",
+	"Hangul":     "ko",
+	"Han":        "zh",
+	"Hebrew":     "he",
+	"Kana":       "ja",
+	"Thai":       "th",
+}
+
+// languageScripts are the scripts counted when detecting a language, with
+// Hiragana and Katakana counted together as Kana.
+var languageScripts = []struct {
+	name  string
+	table *unicode.RangeTable
+}{
+	{"Latin", unicode.Latin},
+	{"Cyrillic", unicode.Cyrillic},
+	{"Arabic", unicode.Arabic},
+	{"Devanagari", unicode.Devanagari},
+	{"Greek", unicode.Greek},
+	{"Hangul", unicode.Hangul},
+	{"Han", unicode.Han},
+	{"Hebrew", unicode.Hebrew},
+	{"Kana", unicode.Hiragana},
+	{"Kana", unicode.Katakana},
+	{"Thai", unicode.Thai},
+}
+
+// languageProfile ranks the most frequent n-grams of a language's sample.
+type languageProfile struct {
+	code   string
+	script string
+	ranks  map[string]int
+}
+
+// languageProfiles are built once from the embedded samples.
+var languageProfiles = loadLanguageProfiles()
+
+// LanguageInfo compares the detected language of the page text with the
+// languages the page declares.
+type LanguageInfo struct {
+	Language        string    `json:"language"`   // ISO 639-1 code, empty when undetermined
+	Confidence      float64   `json:"confidence"` // 0-1
+	HTMLLang        string    `json:"html_lang"`
+	ContentLanguage string    `json:"content_language"`
+	Findings        []Finding `json:"findings"`
+}
+
+// loadLanguageProfiles builds the n-gram profile of every embedded sample.
+func loadLanguageProfiles() []languageProfile {
+	entries, err := languageSamples.ReadDir("langdata")
+	if err != nil {
+		panic(fmt.Sprintf("failed to read language samples: %v", err))
+	}
+	profiles := make([]languageProfile, 0, len(entries))
+	for _, entry := range entries {
+		sample, err := languageSamples.ReadFile(path.Join("langdata", entry.Name()))
+		if err != nil {
+			panic(fmt.Sprintf("failed to read language sample %s: %v", entry.Name(), err))
+		}
+		text := string(sample)
+		script, _ := dominantScript(text)
+		profiles = append(profiles, languageProfile{
+			code:   strings.TrimSuffix(entry.Name(), ".txt"),
+			script: script,
+			ranks:  ngramRanks(text),
+		})
+	}
+	return profiles
+}
+
+// detectLanguage returns the language of a text and a confidence between 0
+// and 1, or an empty code when the text is too short, in an unknown script or
+// not confidently recognized. Latin and Cyrillic text is ranked by the
+// out-of-place distance between its n-gram profile and each language profile
+// (Cavnar and Trenkle); confidence grows with how closely the best profile
+// fits and how far it leads the runner-up. Other scripts map to a language
+// directly. Either way confidence is scaled by the script's share of letters.
+func detectLanguage(text string) (string, float64) {
+	if runes := []rune(text); len(runes) > maxLanguageSample {
+		text = string(runes[:maxLanguageSample])
+	}
+	script, share := dominantScript(text)
+	if script == "" {
+		return "", 0
+	}
+	if code, ok := scriptLanguages[script]; ok {
+		return confidentLanguage(code, share)
+	}
+
+	ranks := ngramRanks(text)
+	maxDistance := float64(len(ranks) * ngramProfileSize)
+	best, bestDistance, secondDistance := "", 1.0, 1.0
+	for _, profile := range languageProfiles {
+		if profile.script != script {
+			continue
+		}
+		distance := 0
+		for ngram, rank := range ranks {
+			if langRank, ok := profile.ranks[ngram]; ok {
+				distance += abs(rank - langRank)
+			} else {
+				distance += ngramProfileSize
+			}
+		}
+		normalized := float64(distance) / maxDistance
+		switch {
+		case best == "" || normalized < bestDistance:
+			secondDistance = bestDistance
+			best, bestDistance = profile.code, normalized
+		case normalized < secondDistance:
+			secondDistance = normalized
+		}
+	}
+	if best == "" {
+		return "", 0
+	}
+
+	fit := clamp01((unknownFitDistance - bestDistance) / (unknownFitDistance - fullFitDistance))
+	margin := clamp01((secondDistance - bestDistance) / secondDistance / fullFitMargin)
+	return confidentLanguage(best, fit*margin*share)
+}
+
+// confidentLanguage returns a detected language with its rounded confidence,
+// or no language when the confidence is below minLanguageConfidence.
+func confidentLanguage(code string, confidence float64) (string, float64) {
+	if confidence < minLanguageConfidence {
+		return "", 0
+	}
+	return code, roundTo(confidence, 2)
+}
+
+// dominantScript returns the script of most letters of a text and the share
+// of letters written in it, or an empty script for texts with too few letters.
+func dominantScript(text string) (string, float64) {
+	counts := make(map[string]int)
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		for _, s := range languageScripts {
+			if unicode.Is(s.table, r) {
+				counts[s.name]++
+				break
+			}
+		}
+	}
+	if letters < minLanguageLetters {
+		return "", 0
+	}
+
+	script, count := "", 0
+	for name, n := range counts {
+		if n > count || (n == count && name < script) {
+			script, count = name, n
+		}
+	}
+	// Japanese mixes Kanji with Kana, so any substantial Kana marks Japanese
+	if script == "Han" && counts["Kana"]*5 >= counts["Han"] {
+		script, count = "Kana", counts["Han"]+counts["Kana"]
+	}
+	if script == "" {
+		return "", 0
+	}
+	return script, float64(count) / float64(letters)
+}
+
+// ngramRanks ranks the 1- to maxNgramLength-rune n-grams of the words of a
+// text by frequency, keeping the ngramProfileSize most frequent. Words are
+// lowercased and padded with "_" so that n-grams capture word boundaries.
+func ngramRanks(text string) map[string]int {
+	counts := make(map[string]int)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) })
+	for _, word := range words {
+		runes := []rune("_" + word + "_")
+		for n := 1; n <= maxNgramLength; n++ {
+			for i := 0; i+n <= len(runes); i++ {
+				if ngram := string(runes[i : i+n]); ngram != "_" {
+					counts[ngram]++
+				}
+			}
+		}
+	}
+
+	ngrams := make([]string, 0, len(counts))
+	for ngram := range counts {
+		ngrams = append(ngrams, ngram)
+	}
+	sort.Slice(ngrams, func(i, j int) bool {
+		if counts[ngrams[i]] != counts[ngrams[j]] {
+			return counts[ngrams[i]] > counts[ngrams[j]]
+		}
+		return ngrams[i] < ngrams[j]
+	})
+	if len(ngrams) > ngramProfileSize {
+		ngrams = ngrams[:ngramProfileSize]
+	}
+	ranks := make(map[string]int, len(ngrams))
+	for i, ngram := range ngrams {
+		ranks[ngram] = i
+	}
+	return ranks
+}
+
+// analyzeLanguage detects the language of the page text and flags a <html
+// lang> attribute or Content-Language header naming a different language.
+// Declared languages the detector does not know are not compared.
+func analyzeLanguage(text, htmlLang, contentLanguage string) *LanguageInfo {
+	info := &LanguageInfo{
+		HTMLLang:        strings.TrimSpace(htmlLang),
+		ContentLanguage: strings.TrimSpace(contentLanguage),
+		Findings:        []Finding{},
+	}
+	info.Language, info.Confidence = detectLanguage(text)
+	if info.Language == "" {
+		return info
+	}
+
+	if declared := primaryLanguage(info.HTMLLang); supportedLanguage(declared) && declared != info.Language {
+		info.Findings = append(info.Findings, Finding{
+			Code:     LangHTMLMismatch,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("<html lang=%q> does not match the detected language %q", info.HTMLLang, info.Language),
+		})
+	}
+
+	var declared []string
+	for _, tag := range strings.Split(info.ContentLanguage, ",") {
+		if code := primaryLanguage(tag); supportedLanguage(code) {
+			declared = append(declared, code)
+		}
+	}
+	if len(declared) > 0 && !slices.Contains(declared, info.Language) {
+		info.Findings = append(info.Findings, Finding{
+			Code:     LangContentLanguageMismatch,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("Content-Language %q does not include the detected language %q", info.ContentLanguage, info.Language),
+		})
+	}
+	return info
+}
+
+// primaryLanguage returns the lowercased primary subtag of a language tag,
+// such as "en" for "en-US".
+func primaryLanguage(tag string) string {
+	tag = strings.TrimSpace(tag)
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	return strings.ToLower(tag)
+}
+
+// supportedLanguage reports whether the detector can recognize a language.
+func supportedLanguage(code string) bool {
+	for _, profile := range languageProfiles {
+		if profile.code == code {
+			return true
+		}
+	}
+	for _, language := range scriptLanguages {
+		if language == code {
+			return true
+		}
+	}
+	return false
+}
+
+// clamp01 limits x to the range 0-1.
+func clamp01(x float64) float64 {
+	return max(0, min(1, x))
+}
+
+// abs returns the absolute value of an integer.
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
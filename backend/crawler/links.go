@@ -0,0 +1,363 @@
+package crawler
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+// maxAnchorTextLength caps the stored anchor text of a link, in runes.
+const maxAnchorTextLength = 500
+
+// Link error types classifying why a checked link is broken.
+const (
+	LinkErrorClient     = "client_error"
+	LinkErrorServer     = "server_error"
+	LinkErrorDNS        = "dns_failure"
+	LinkErrorTimeout    = "timeout"
+	LinkErrorTLS        = "tls_error"
+	LinkErrorConnection = "connection_error"
+	LinkErrorRedirect   = "redirect_error"
+)
+
+// LinkDetail describes a single link found on a page and the outcome of checking it.
+type LinkDetail struct {
+	Href        string `json:"href"`
+	URL         string `json:"url"`
+	AnchorText  string `json:"anchor_text"`
+	Rel         string `json:"rel"`
+	Internal    bool   `json:"internal"`
+	Checked     bool   `json:"checked"`
+	Broken      bool   `json:"broken"`
+	StatusCode  int    `json:"status_code"`
+	ErrorType   string `json:"error_type,omitempty"`
+	Error       string `json:"error,omitempty"`
+	RedirectURL string `json:"redirect_url,omitempty"`
+
+	// RedirectChain holds the redirects followed when checking the link.
+	RedirectChain        []RedirectHop `json:"redirect_chain,omitempty"`
+	RedirectChainTooLong bool          `json:"redirect_chain_too_long"`
+	RedirectLoop         bool          `json:"redirect_loop"`
+
+	robotsSkipped bool
+}
+
+// newLinkDetail builds the details of an anchor element with the given href,
+// resolving it against baseURL. A link is internal when it resolves to the
+// host of the page URL.
+func newLinkDetail(pageURL, baseURL string, n *html.Node, href string) LinkDetail {
+	link := LinkDetail{
+		Href:       href,
+		AnchorText: anchorText(n),
+		Rel:        getAttr(n, "rel"),
+		Internal:   isInternalLink(pageURL, href),
+	}
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return link
+	}
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return link
+	}
+	resolved := base.ResolveReference(ref)
+	link.URL = resolved.String()
+	if page, err := url.Parse(pageURL); err == nil {
+		link.Internal = resolved.Host == page.Host
+	}
+	return link
+}
+
+// anchorText returns the collapsed text content of a link, using image alt
+// text when the link has no text of its own.
+func anchorText(n *html.Node) string {
+	var text, alt strings.Builder
+	var traverse func(*html.Node)
+	traverse = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			text.WriteString(n.Data)
+			text.WriteString(" ")
+		case n.Type == html.ElementNode && n.Data == "img":
+			alt.WriteString(getAttr(n, "alt"))
+			alt.WriteString(" ")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			traverse(c)
+		}
+	}
+	traverse(n)
+
+	result := strings.Join(strings.Fields(text.String()), " ")
+	if result == "" {
+		result = strings.Join(strings.Fields(alt.String()), " ")
+	}
+	if runes := []rune(result); len(runes) > maxAnchorTextLength {
+		result = string(runes[:maxAnchorTextLength])
+	}
+	return result
+}
+
+// checkBrokenLinks checks internal and external links for broken targets and
+// records the outcome on each link. Each distinct URL is checked once by a
+// bounded pool of workers with a per-host concurrency limit, and the call
+// returns once every URL has been checked or the link check deadline has
+// passed. Non-HTTP links, links disallowed by robots.txt and links not reached
+// before the deadline are left unchecked.
+func (c *Crawler) checkBrokenLinks(ctx context.Context, data *CrawlData, opts Options) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.LinkCheckTimeout)
+	defer cancel()
+
+	// Group links by URL without fragment so repeated links are checked once
+	targets := make(map[string][]*LinkDetail)
+	var order []string
+	for i := range data.Links {
+		link := &data.Links[i]
+		target, ok := resolveCrawlableURL(link.URL, "")
+		if !ok {
+			continue
+		}
+		if _, seen := targets[target]; !seen {
+			order = append(order, target)
+		}
+		targets[target] = append(targets[target], link)
+	}
+
+	results := make([]LinkDetail, len(order))
+	hosts := newHostLimiter(c.cfg.LinkCheckPerHost)
+	runWorkers(ctx, c.cfg.LinkCheckWorkers, len(order), func(j int) {
+		results[j] = c.checkLink(ctx, hosts, order[j], opts)
+	})
+
+	// Copy the outcomes onto every link once all workers are done so data is only written here
+	for j, target := range order {
+		result := results[j]
+		if !result.Checked && !result.robotsSkipped && result.Error == "" {
+			result.Error = "link check deadline exceeded"
+		}
+		for _, link := range targets[target] {
+			link.Checked = result.Checked
+			link.Broken = result.Broken
+			link.StatusCode = result.StatusCode
+			link.ErrorType = result.ErrorType
+			link.Error = result.Error
+			link.RedirectURL = result.RedirectURL
+			link.RedirectChain = result.RedirectChain
+			link.RedirectChainTooLong = result.RedirectChainTooLong
+			link.RedirectLoop = result.RedirectLoop
+			link.robotsSkipped = result.robotsSkipped
+		}
+	}
+	for i := range data.Links {
+		link := &data.Links[i]
+		switch {
+		case link.Broken:
+			data.BrokenLinks++
+		case link.robotsSkipped:
+			data.RobotsSkippedLinks = append(data.RobotsSkippedLinks, link.URL)
+		}
+	}
+}
+
+// checkLink checks a single URL, waiting for a free slot for its host. It
+// tries HEAD first and falls back to a ranged GET when the server rejects HEAD.
+func (c *Crawler) checkLink(ctx context.Context, hosts *hostLimiter, target string, opts Options) LinkDetail {
+	var result LinkDetail
+	if !opts.IgnoreRobots {
+		if !c.robots.allowed(ctx, target) {
+			result.robotsSkipped = true
+			result.Error = ErrDisallowedByRobots.Error()
+			return result
+		}
+		if err := c.robots.wait(ctx, target); err != nil {
+			return result
+		}
+	}
+
+	host := ""
+	if u, err := url.Parse(target); err == nil {
+		host = u.Host
+	}
+	if err := hosts.acquire(ctx, host); err != nil {
+		return result
+	}
+	defer hosts.release(host)
+
+	resp, chain, err := c.fetch(ctx, http.MethodHead, target, nil)
+	if err == nil {
+		resp.Body.Close()
+	}
+	if headRejected(resp, err) && ctx.Err() == nil {
+		resp, chain, err = c.rangedGet(ctx, target)
+	}
+	result.RedirectChain = chain
+	result.RedirectChainTooLong = len(chain) > c.cfg.RedirectWarnHops
+	result.RedirectLoop = errors.Is(err, ErrRedirectLoop)
+	if err != nil {
+		// Requests cut short by the deadline say nothing about the link
+		if ctx.Err() != nil {
+			return result
+		}
+		result.Checked = true
+		result.Broken = true
+		result.ErrorType = classifyLinkError(err)
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Checked = true
+	result.StatusCode = resp.StatusCode
+	if len(chain) > 0 {
+		result.RedirectURL = finalURL(resp, target)
+	}
+	switch {
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		// The resource exists; it just cannot serve the requested byte range
+	case resp.StatusCode >= 500:
+		result.Broken = true
+		result.ErrorType = LinkErrorServer
+	case resp.StatusCode >= 400:
+		result.Broken = true
+		result.ErrorType = LinkErrorClient
+	}
+	return result
+}
+
+// headRejected reports whether a HEAD response suggests the server does not
+// support HEAD and the link should be retried with GET.
+func headRejected(resp *http.Response, err error) bool {
+	if err != nil {
+		// DNS, TLS and timeout failures would fail the same way with GET
+		return classifyLinkError(err) == LinkErrorConnection
+	}
+	switch resp.StatusCode {
+	case http.StatusBadRequest, http.StatusForbidden, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
+
+// rangedGet requests only the first byte of a resource and closes the body.
+func (c *Crawler) rangedGet(ctx context.Context, target string) (*http.Response, []RedirectHop, error) {
+	resp, chain, err := c.fetch(ctx, http.MethodGet, target, http.Header{"Range": {"bytes=0-0"}})
+	if err != nil {
+		return nil, chain, err
+	}
+	// Servers ignoring Range send the whole body, so drain only a little
+	io.CopyN(io.Discard, resp.Body, 1024)
+	resp.Body.Close()
+	return resp, chain, nil
+}
+
+// classifyLinkError maps a request error to one of the LinkError types.
+func classifyLinkError(err error) string {
+	var dnsErr *net.DNSError
+	var certErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+	var unknownAuthErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	var netErr net.Error
+	switch {
+	case isRedirectError(err):
+		return LinkErrorRedirect
+	case errors.As(err, &dnsErr):
+		return LinkErrorDNS
+	case errors.As(err, &certErr), errors.As(err, &recordErr), errors.As(err, &unknownAuthErr),
+		errors.As(err, &hostnameErr), errors.As(err, &invalidErr):
+		return LinkErrorTLS
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return LinkErrorTimeout
+	default:
+		return LinkErrorConnection
+	}
+}
+
+// runWorkers calls work for every index below n on a pool of workers. It
+// stops handing out indexes once the context is done and returns when all
+// started calls have finished.
+func runWorkers(ctx context.Context, workers, n int, work func(i int)) {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				work(j)
+			}
+		}()
+	}
+
+enqueue:
+	for j := 0; j < n; j++ {
+		select {
+		case jobs <- j:
+		case <-ctx.Done():
+			break enqueue
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// hostLimiter bounds the number of concurrent requests per host.
+type hostLimiter struct {
+	limit int
+
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+// newHostLimiter creates a limiter allowing limit concurrent requests per host.
+func newHostLimiter(limit int) *hostLimiter {
+	if limit < 1 {
+		limit = 1
+	}
+	return &hostLimiter{limit: limit, slots: make(map[string]chan struct{})}
+}
+
+// acquire blocks until a slot for host is free or the context is done.
+func (h *hostLimiter) acquire(ctx context.Context, host string) error {
+	h.mu.Lock()
+	slot, ok := h.slots[host]
+	if !ok {
+		slot = make(chan struct{}, h.limit)
+		h.slots[host] = slot
+	}
+	h.mu.Unlock()
+
+	select {
+	case slot <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees a slot previously acquired for host.
+func (h *hostLimiter) release(host string) {
+	h.mu.Lock()
+	slot := h.slots[host]
+	h.mu.Unlock()
+	<-slot
+}
+
+// getAttr returns the value of the named attribute, or an empty string.
+func getAttr(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,166 @@
+package crawler
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Mixed content types. Browsers block active mixed content, which can alter
+// the page, and only warn about passive mixed content such as images.
+const (
+	MixedContentActive  = "active"
+	MixedContentPassive = "passive"
+)
+
+// cssURLPattern matches url() references and @import rules in CSS, capturing
+// the @import keyword (if any) and the referenced URL.
+var cssURLPattern = regexp.MustCompile(`(?i)(@import\s+)?(?:url\(\s*['"]?([^'")\s]+)['"]?\s*\)|['"]([^'"]+)['"])`)
+
+// cssRef is a resource referenced from CSS.
+type cssRef struct {
+	url      string
+	isImport bool // referenced by an @import rule rather than url()
+}
+
+// cssRefs returns the url() references and @import rules of CSS, in order.
+func cssRefs(css string) []cssRef {
+	var refs []cssRef
+	for _, m := range cssURLPattern.FindAllStringSubmatch(css, -1) {
+		ref := cssRef{url: m[2], isImport: m[1] != ""}
+		if ref.url == "" {
+			// Quoted strings only reference resources in @import rules
+			if !ref.isImport {
+				continue
+			}
+			ref.url = m[3]
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// srcsetURLs returns the candidate URLs of an element's srcset attribute.
+func srcsetURLs(n *html.Node) []string {
+	var refs []string
+	for _, candidate := range strings.Split(getAttr(n, "srcset"), ",") {
+		if fields := strings.Fields(candidate); len(fields) > 0 {
+			refs = append(refs, fields[0])
+		}
+	}
+	return refs
+}
+
+// activeLinkRels are <link> relations that load resources able to alter the page.
+var activeLinkRels = map[string]bool{
+	"stylesheet":    true,
+	"preload":       true,
+	"modulepreload": true,
+	"manifest":      true,
+}
+
+// passiveLinkRels are <link> relations that load resources unable to alter
+// the page. Other relations, like canonical or preconnect, load nothing.
+var passiveLinkRels = map[string]bool{
+	"icon":             true,
+	"apple-touch-icon": true,
+}
+
+// MixedContent is an insecure http:// subresource referenced by an HTTPS page.
+type MixedContent struct {
+	URL       string `json:"url"`
+	Type      string `json:"type"`      // active or passive
+	Element   string `json:"element"`   // tag name of the referencing element
+	Attribute string `json:"attribute"` // attribute holding the reference, or "style" for CSS
+	Path      string `json:"path"`
+}
+
+// findMixedContent lists the http:// subresources referenced by the document,
+// resolved against baseURL. It is only meaningful for pages served over HTTPS.
+func findMixedContent(doc *html.Node, baseURL string) []MixedContent {
+	var found []MixedContent
+	add := func(n *html.Node, attr, ref, kind string) {
+		ref = strings.TrimSpace(ref)
+		if ref == "" {
+			return
+		}
+		resolved := resolveURL(baseURL, ref)
+		if !strings.HasPrefix(strings.ToLower(resolved), "http://") {
+			return
+		}
+		found = append(found, MixedContent{
+			URL:       resolved,
+			Type:      kind,
+			Element:   n.Data,
+			Attribute: attr,
+			Path:      elementPath(n),
+		})
+	}
+	addAttr := func(n *html.Node, attr, kind string) {
+		if v, ok := attrValue(n, attr); ok {
+			add(n, attr, v, kind)
+		}
+	}
+	addSrcset := func(n *html.Node, kind string) {
+		for _, ref := range srcsetURLs(n) {
+			add(n, "srcset", ref, kind)
+		}
+	}
+	addCSS := func(n *html.Node, attr, css string) {
+		for _, ref := range cssRefs(css) {
+			kind := MixedContentPassive
+			if ref.isImport {
+				kind = MixedContentActive
+			}
+			add(n, attr, ref.url, kind)
+		}
+	}
+
+	var traverse func(*html.Node)
+	traverse = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "script", "iframe", "frame", "embed":
+				addAttr(n, "src", MixedContentActive)
+			case "object":
+				addAttr(n, "data", MixedContentActive)
+			case "form":
+				addAttr(n, "action", MixedContentActive)
+			case "link":
+				kind := ""
+				for _, rel := range strings.Fields(strings.ToLower(getAttr(n, "rel"))) {
+					if activeLinkRels[rel] {
+						kind = MixedContentActive
+					} else if passiveLinkRels[rel] && kind == "" {
+						kind = MixedContentPassive
+					}
+				}
+				if kind != "" {
+					addAttr(n, "href", kind)
+				}
+			case "img":
+				addAttr(n, "src", MixedContentPassive)
+				addSrcset(n, MixedContentPassive)
+			case "video":
+				addAttr(n, "src", MixedContentPassive)
+				addAttr(n, "poster", MixedContentPassive)
+			case "audio", "track":
+				addAttr(n, "src", MixedContentPassive)
+			case "source":
+				addAttr(n, "src", MixedContentPassive)
+				addSrcset(n, MixedContentPassive)
+			case "style":
+				addCSS(n, "style", textContent(n))
+			}
+			if style, ok := attrValue(n, "style"); ok {
+				addCSS(n, "style", style)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			traverse(c)
+		}
+	}
+	traverse(doc)
+	return found
+}
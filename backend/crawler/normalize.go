@@ -0,0 +1,44 @@
+package crawler
+
+import (
+	"net/url"
+	"strings"
+)
+
+// NormalizeURL returns the form of a URL used to group crawls of the same
+// page: the scheme and host are lowercased, default ports, fragments and
+// empty query strings are dropped, an empty path becomes "/" and query
+// parameters are sorted. URLs that do not parse are returned trimmed.
+func NormalizeURL(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	u.Host = host
+
+	u.Fragment = ""
+	u.RawFragment = ""
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	// Encode sorts parameters by key, keeping the order of repeated keys
+	if u.RawQuery != "" {
+		u.RawQuery = u.Query().Encode()
+	}
+	u.ForceQuery = false
+	return u.String()
+}
@@ -0,0 +1,100 @@
+package crawler
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Heading outline finding codes.
+const (
+	OutlineMissingH1    = "missing_h1"
+	OutlineMultipleH1   = "multiple_h1"
+	OutlineLevelJump    = "heading_level_jump"
+	OutlineEmptyHeading = "empty_heading"
+)
+
+// Heading is a heading in the document outline with the headings nested below it.
+type Heading struct {
+	Level    int        `json:"level"`
+	Text     string     `json:"text"`
+	Children []*Heading `json:"children,omitempty"`
+}
+
+// HeadingOutline is the document outline built from h1-h6 elements.
+type HeadingOutline struct {
+	Headings []*Heading `json:"headings"`
+	Findings []Finding  `json:"findings"`
+}
+
+// buildOutline builds the nested heading outline of the document in document
+// order and reports structural problems.
+func buildOutline(doc *html.Node) *HeadingOutline {
+	outline := &HeadingOutline{}
+	var stack []*Heading
+	h1Count, previous := 0, 0
+
+	add := func(code, severity, message string, n *html.Node) {
+		f := Finding{Code: code, Severity: severity, Message: message}
+		if n != nil {
+			f.Path = elementPath(n)
+		}
+		outline.Findings = append(outline.Findings, f)
+	}
+
+	var traverse func(*html.Node)
+	traverse = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if level := headingLevel(n.Data); level > 0 {
+				heading := &Heading{Level: level, Text: strings.Join(strings.Fields(textContent(n)), " ")}
+				if heading.Text == "" {
+					add(OutlineEmptyHeading, SeverityWarning, fmt.Sprintf("h%d has no text", level), n)
+				}
+				if level == 1 {
+					h1Count++
+				}
+				if previous > 0 && level > previous+1 {
+					add(OutlineLevelJump, SeverityWarning,
+						fmt.Sprintf("heading level jumps from h%d to h%d", previous, level), n)
+				}
+				previous = level
+
+				// Nest under the closest preceding heading of a higher level
+				for len(stack) > 0 && stack[len(stack)-1].Level >= level {
+					stack = stack[:len(stack)-1]
+				}
+				if len(stack) == 0 {
+					outline.Headings = append(outline.Headings, heading)
+				} else {
+					parent := stack[len(stack)-1]
+					parent.Children = append(parent.Children, heading)
+				}
+				stack = append(stack, heading)
+
+				// Headings cannot contain headings, so skip the subtree
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			traverse(c)
+		}
+	}
+	traverse(doc)
+
+	switch {
+	case h1Count == 0:
+		add(OutlineMissingH1, SeverityError, "page has no h1", nil)
+	case h1Count > 1:
+		add(OutlineMultipleH1, SeverityWarning, fmt.Sprintf("page has %d h1 headings", h1Count), nil)
+	}
+	return outline
+}
+
+// headingLevel returns the level of an h1-h6 tag name, or 0 for other tags.
+func headingLevel(tag string) int {
+	if len(tag) == 2 && tag[0] == 'h' && tag[1] >= '1' && tag[1] <= '6' {
+		return int(tag[1] - '0')
+	}
+	return 0
+}
@@ -0,0 +1,43 @@
+package crawler
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestBuildOutlineReportsLevelJump(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(
+		`<html><body><h1>Title</h1><h2>Section</h2><h4>Detail</h4><h2>Next</h2></body></html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outline := buildOutline(doc)
+	if len(outline.Findings) != 1 {
+		t.Fatalf("findings = %v, want one level jump", findingCodes(outline.Findings))
+	}
+	jump := outline.Findings[0]
+	if jump.Code != OutlineLevelJump || jump.Message != "heading level jumps from h2 to h4" ||
+		jump.Path != "html > body > h4" {
+		t.Errorf("finding = %+v, want the h2 to h4 jump at the h4", jump)
+	}
+
+	// The jumped-to heading still nests under the preceding h2
+	if len(outline.Headings) != 1 || len(outline.Headings[0].Children) != 2 ||
+		len(outline.Headings[0].Children[0].Children) != 1 {
+		t.Fatalf("outline = %+v, want h1 > [h2 > h4, h2]", outline.Headings)
+	}
+
+	// The accessibility audit reports the same jump under its own code
+	var skipped []Finding
+	for _, f := range analyzeAccessibility(doc, outline) {
+		if f.Code == A11ySkippedHeading {
+			skipped = append(skipped, f)
+		}
+	}
+	if len(skipped) != 1 || skipped[0].Message != jump.Message || skipped[0].Path != jump.Path {
+		t.Errorf("accessibility findings = %+v, want the outline's jump as %s", skipped, A11ySkippedHeading)
+	}
+}
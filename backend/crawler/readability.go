@@ -0,0 +1,182 @@
+package crawler
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// maxKeywords is the number of keywords reported per page.
+const maxKeywords = 10
+
+// stopWords are common English words left out of keyword counts.
+var stopWords = map[string]bool{
+	"about": true, "after": true, "all": true, "also": true, "and": true, "any": true,
+	"are": true, "because": true, "been": true, "before": true, "but": true, "can": true,
+	"could": true, "did": true, "does": true, "each": true, "for": true, "from": true,
+	"had": true, "has": true, "have": true, "her": true, "here": true, "his": true,
+	"how": true, "into": true, "its": true, "just": true, "more": true, "most": true,
+	"not": true, "now": true, "only": true, "other": true, "our": true, "out": true,
+	"over": true, "she": true, "should": true, "some": true, "such": true, "than": true,
+	"that": true, "the": true, "their": true, "them": true, "then": true, "there": true,
+	"these": true, "they": true, "this": true, "those": true, "through": true, "very": true,
+	"was": true, "were": true, "what": true, "when": true, "where": true, "which": true,
+	"while": true, "who": true, "will": true, "with": true, "would": true, "you": true,
+	"your": true,
+}
+
+// Readability holds text statistics of the page's main content. Syllable
+// counts, and so both Flesch scores, assume English text.
+type Readability struct {
+	WordCount          int       `json:"word_count"`
+	SentenceCount      int       `json:"sentence_count"`
+	SyllableCount      int       `json:"syllable_count"`
+	WordsPerSentence   float64   `json:"words_per_sentence"`
+	SyllablesPerWord   float64   `json:"syllables_per_word"`
+	FleschReadingEase  float64   `json:"flesch_reading_ease"`  // usually 0-100, higher is easier
+	FleschKincaidGrade float64   `json:"flesch_kincaid_grade"` // US school grade level
+	TextHTMLRatio      float64   `json:"text_html_ratio"`      // visible text bytes per HTML byte, in percent
+	Keywords           []Keyword `json:"keywords"`             // most frequent words, excluding stop words
+}
+
+// Keyword is a frequent word of the page's main content.
+type Keyword struct {
+	Word    string  `json:"word"`
+	Count   int     `json:"count"`
+	Density float64 `json:"density"` // share of all words, in percent
+}
+
+// analyzeReadability computes text statistics of the main text. pageText is
+// the visible text of the whole page and htmlSize the size of the HTML body.
+func analyzeReadability(mainText, pageText string, htmlSize int64) *Readability {
+	r := &Readability{Keywords: []Keyword{}}
+	if htmlSize > 0 {
+		r.TextHTMLRatio = roundTo(float64(len(pageText))/float64(htmlSize)*100, 2)
+	}
+
+	counts := make(map[string]int)
+	for _, line := range strings.Split(mainText, "\n") {
+		for _, sentence := range splitSentences(line) {
+			words := splitWords(sentence)
+			if len(words) == 0 {
+				continue
+			}
+			r.SentenceCount++
+			r.WordCount += len(words)
+			for _, word := range words {
+				r.SyllableCount += countSyllables(word)
+				lower := strings.ToLower(word)
+				if len([]rune(lower)) >= 3 && !stopWords[lower] && !isNumber(lower) {
+					counts[lower]++
+				}
+			}
+		}
+	}
+	if r.WordCount == 0 {
+		return r
+	}
+
+	r.WordsPerSentence = float64(r.WordCount) / float64(r.SentenceCount)
+	r.SyllablesPerWord = float64(r.SyllableCount) / float64(r.WordCount)
+	r.FleschReadingEase = roundTo(206.835-1.015*r.WordsPerSentence-84.6*r.SyllablesPerWord, 1)
+	r.FleschKincaidGrade = roundTo(0.39*r.WordsPerSentence+11.8*r.SyllablesPerWord-15.59, 1)
+	r.WordsPerSentence = roundTo(r.WordsPerSentence, 2)
+	r.SyllablesPerWord = roundTo(r.SyllablesPerWord, 2)
+	r.Keywords = topKeywords(counts, r.WordCount)
+	return r
+}
+
+// splitSentences splits a line of text after sentence-ending punctuation.
+// A line without such punctuation, like a heading, is a single sentence.
+func splitSentences(line string) []string {
+	var sentences []string
+	start := 0
+	runes := []rune(line)
+	for i, r := range runes {
+		if r != '.' && r != '!' && r != '?' {
+			continue
+		}
+		// Only split where the punctuation run ends before a space
+		if i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) {
+			continue
+		}
+		sentences = append(sentences, string(runes[start:i+1]))
+		start = i + 1
+	}
+	return append(sentences, string(runes[start:]))
+}
+
+// splitWords returns the words of a text: runs of letters and digits, with
+// inner apostrophes and hyphens kept.
+func splitWords(text string) []string {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\'' && r != '’' && r != '-'
+	})
+	words := fields[:0]
+	for _, f := range fields {
+		if f = strings.Trim(f, "'’-"); f != "" {
+			words = append(words, f)
+		}
+	}
+	return words
+}
+
+// countSyllables estimates the syllables of an English word by counting
+// groups of vowels, ignoring a silent final e.
+func countSyllables(word string) int {
+	word = strings.ToLower(word)
+	count, previousVowel := 0, false
+	for _, r := range word {
+		vowel := strings.ContainsRune("aeiouy", r)
+		if vowel && !previousVowel {
+			count++
+		}
+		previousVowel = vowel
+	}
+	if strings.HasSuffix(word, "e") && !strings.HasSuffix(word, "le") && count > 1 {
+		count--
+	}
+	if count == 0 {
+		count = 1
+	}
+	return count
+}
+
+// topKeywords returns the most frequent words, breaking ties alphabetically.
+func topKeywords(counts map[string]int, wordCount int) []Keyword {
+	keywords := make([]Keyword, 0, len(counts))
+	for word, count := range counts {
+		keywords = append(keywords, Keyword{
+			Word:    word,
+			Count:   count,
+			Density: roundTo(float64(count)/float64(wordCount)*100, 2),
+		})
+	}
+	sort.Slice(keywords, func(i, j int) bool {
+		if keywords[i].Count != keywords[j].Count {
+			return keywords[i].Count > keywords[j].Count
+		}
+		return keywords[i].Word < keywords[j].Word
+	})
+	if len(keywords) > maxKeywords {
+		keywords = keywords[:maxKeywords]
+	}
+	return keywords
+}
+
+// isNumber reports whether a word consists of digits only.
+func isNumber(word string) bool {
+	for _, r := range word {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// roundTo rounds x to the given number of decimal places.
+func roundTo(x float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(x*scale) / scale
+}
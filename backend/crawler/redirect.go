@@ -0,0 +1,150 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxRedirectHops is the hard limit of redirects followed for a single request.
+const maxRedirectHops = 20
+
+// Redirect errors returned when a redirect chain cannot be followed to the end.
+var (
+	ErrRedirectLoop       = errors.New("redirect loop")
+	ErrTooManyRedirects   = errors.New("too many redirects")
+	ErrRedirectNoLocation = errors.New("redirect without Location header")
+)
+
+// RedirectHop is a single redirect response in a redirect chain.
+type RedirectHop struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+	Location   string `json:"location"`
+}
+
+// fetch sends a request with the crawler's headers and the given extra
+// headers, following redirects itself so that every hop is recorded. The
+// crawler's site headers are only sent to hops on its site host and its
+// subdomains. It returns the final response together with the redirect chain
+// that led to it. The chain is also returned alongside redirect errors.
+func (c *Crawler) fetch(ctx context.Context, method, rawURL string, header http.Header) (*http.Response, []RedirectHop, error) {
+	var chain []RedirectHop
+	visited := map[string]bool{}
+	current := rawURL
+
+	for {
+		req, err := http.NewRequestWithContext(ctx, method, current, nil)
+		if err != nil {
+			return nil, chain, err
+		}
+		req.Header.Set("User-Agent", UserAgent)
+		headers := []http.Header{c.header, header}
+		if c.siteHost != "" && isSameOrSubdomain(req.URL.Hostname(), c.siteHost) {
+			headers = append(headers, c.siteHeader)
+		}
+		for _, h := range headers {
+			for key, values := range h {
+				req.Header[key] = values
+			}
+		}
+
+		resp, err := c.fetcher.Do(req)
+		if err != nil {
+			return nil, chain, err
+		}
+		if !isRedirect(resp.StatusCode) {
+			return resp, chain, nil
+		}
+		resp.Body.Close()
+
+		location := resp.Header.Get("Location")
+		chain = append(chain, RedirectHop{URL: current, StatusCode: resp.StatusCode, Location: location})
+		visited[current] = true
+		if location == "" {
+			return nil, chain, fmt.Errorf("%w at %s", ErrRedirectNoLocation, current)
+		}
+
+		next, err := req.URL.Parse(location)
+		if err != nil {
+			return nil, chain, fmt.Errorf("invalid redirect location %q: %w", location, err)
+		}
+		current = next.String()
+		if visited[current] {
+			return nil, chain, fmt.Errorf("%w at %s", ErrRedirectLoop, current)
+		}
+		if len(chain) >= maxRedirectHops {
+			return nil, chain, fmt.Errorf("%w: stopped after %d hops", ErrTooManyRedirects, len(chain))
+		}
+	}
+}
+
+// crawlBrokenRedirect returns the crawl data of a page whose redirect chain
+// could not be followed to the end. The final URL is where the chain stopped
+// and the status code is that of its last hop.
+func (c *Crawler) crawlBrokenRedirect(targetURL string, chain []RedirectHop, err error, startTime time.Time) *CrawlData {
+	data := &CrawlData{
+		URL:                  targetURL,
+		FinalURL:             targetURL,
+		RedirectChain:        chain,
+		RedirectChainTooLong: len(chain) > c.cfg.RedirectWarnHops || errors.Is(err, ErrTooManyRedirects),
+		RedirectLoop:         errors.Is(err, ErrRedirectLoop),
+		ProcessingTime:       time.Since(startTime).Seconds(),
+	}
+	if len(chain) > 0 {
+		last := chain[len(chain)-1]
+		data.FinalURL = last.URL
+		data.StatusCode = last.StatusCode
+		if u, err := url.Parse(last.URL); err == nil && last.Location != "" {
+			if next, err := u.Parse(last.Location); err == nil {
+				data.FinalURL = next.String()
+			}
+		}
+	}
+	return data
+}
+
+// isSameOrSubdomain reports whether host is parent or one of its subdomains.
+func isSameOrSubdomain(host, parent string) bool {
+	host, parent = strings.ToLower(host), strings.ToLower(parent)
+	return host == parent || strings.HasSuffix(host, "."+parent)
+}
+
+// do sends a request with the crawler's User-Agent, following redirects.
+func (c *Crawler) do(ctx context.Context, method, rawURL string) (*http.Response, error) {
+	resp, _, err := c.fetch(ctx, method, rawURL, nil)
+	return resp, err
+}
+
+// isRedirect reports whether the status code is a redirect with a Location.
+func isRedirect(statusCode int) bool {
+	switch statusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// isRedirectError reports whether err stems from an unfollowable redirect chain.
+func isRedirectError(err error) bool {
+	return errors.Is(err, ErrRedirectLoop) || errors.Is(err, ErrTooManyRedirects) || errors.Is(err, ErrRedirectNoLocation)
+}
+
+// finalURL returns the URL of the last request made for a response.
+func finalURL(resp *http.Response, fallback string) string {
+	if resp == nil || resp.Request == nil || resp.Request.URL == nil {
+		return fallback
+	}
+	return resp.Request.URL.String()
+}
+
+// noRedirects is an http.Client CheckRedirect policy that returns redirect
+// responses to the caller instead of following them.
+func noRedirects(*http.Request, []*http.Request) error {
+	return http.ErrUseLastResponse
+}
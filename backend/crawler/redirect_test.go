@@ -0,0 +1,90 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// newRedirectTestServer serves redirect chains:
+//
+//	/loop        redirects to itself
+//	/hops/n      redirects to /hops/n-1, /hops/0 serves a page
+//	/endless/n   redirects to /endless/n+1 forever
+//	/gone        redirects to /missing, which is not found
+func newRedirectTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch path := r.URL.Path; {
+		case path == "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		case strings.HasPrefix(path, "/hops/"):
+			n, _ := strconv.Atoi(strings.TrimPrefix(path, "/hops/"))
+			if n == 0 {
+				w.Header().Set("Content-Type", "text/html")
+				fmt.Fprint(w, "<html><head><title>Landed</title></head><body></body></html>")
+				return
+			}
+			http.Redirect(w, r, fmt.Sprintf("/hops/%d", n-1), http.StatusMovedPermanently)
+		case strings.HasPrefix(path, "/endless/"):
+			n, _ := strconv.Atoi(strings.TrimPrefix(path, "/endless/"))
+			http.Redirect(w, r, fmt.Sprintf("/endless/%d", n+1), http.StatusFound)
+		case path == "/gone":
+			http.Redirect(w, r, "/missing", http.StatusMovedPermanently)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCrawlRecordsRedirects(t *testing.T) {
+	srv := newRedirectTestServer(t)
+
+	tests := []struct {
+		name        string
+		path        string
+		wantFinal   string
+		wantStatus  int
+		wantHops    int
+		wantTooLong bool
+		wantLoop    bool
+		wantTitle   string
+	}{
+		{"loop", "/loop", "/loop", http.StatusFound, 1, false, true, ""},
+		{"long chain", "/hops/5", "/hops/0", http.StatusOK, 5, true, false, "Landed"},
+		{"endless chain", "/endless/0", "/endless/20", http.StatusFound, maxRedirectHops, true, false, ""},
+		{"ends in 404", "/gone", "/missing", http.StatusNotFound, 1, false, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := NewCrawler(nil).Crawl(context.Background(), srv.URL+tt.path, Options{IgnoreRobots: true})
+			if err != nil {
+				t.Fatalf("Crawl: %v", err)
+			}
+			if data.FinalURL != srv.URL+tt.wantFinal {
+				t.Errorf("FinalURL = %q, want %q", data.FinalURL, srv.URL+tt.wantFinal)
+			}
+			if data.StatusCode != tt.wantStatus {
+				t.Errorf("StatusCode = %d, want %d", data.StatusCode, tt.wantStatus)
+			}
+			if len(data.RedirectChain) != tt.wantHops {
+				t.Errorf("got %d redirect hops, want %d", len(data.RedirectChain), tt.wantHops)
+			}
+			if data.RedirectChainTooLong != tt.wantTooLong {
+				t.Errorf("RedirectChainTooLong = %t, want %t", data.RedirectChainTooLong, tt.wantTooLong)
+			}
+			if data.RedirectLoop != tt.wantLoop {
+				t.Errorf("RedirectLoop = %t, want %t", data.RedirectLoop, tt.wantLoop)
+			}
+			if data.Title != tt.wantTitle {
+				t.Errorf("Title = %q, want %q", data.Title, tt.wantTitle)
+			}
+		})
+	}
+}
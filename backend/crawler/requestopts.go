@@ -0,0 +1,119 @@
+package crawler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+// MaxRequestTimeout is the longest page request timeout a crawl may ask for.
+const MaxRequestTimeout = 2 * time.Minute
+
+// reservedHeaders are request headers that extra headers may not set, either
+// because the HTTP client manages them or because they have options of their own.
+var reservedHeaders = map[string]string{
+	"Host":              "",
+	"Content-Length":    "",
+	"Transfer-Encoding": "",
+	"Connection":        "",
+	"Range":             "",
+	"User-Agent":        "user_agent",
+	"Cookie":            "cookies",
+	"Accept-Language":   "accept_language",
+}
+
+// ErrInvalidRequestOptions is returned for request settings that cannot be sent.
+var ErrInvalidRequestOptions = errors.New("invalid request options")
+
+// ValidateRequestOptions checks the request settings of crawl options: header
+// names and values, cookie names and values, and the timeout.
+func ValidateRequestOptions(opts Options) error {
+	if opts.UserAgent != "" && !httpguts.ValidHeaderFieldValue(opts.UserAgent) {
+		return fmt.Errorf("%w: user agent contains invalid characters", ErrInvalidRequestOptions)
+	}
+	if opts.AcceptLanguage != "" && !httpguts.ValidHeaderFieldValue(opts.AcceptLanguage) {
+		return fmt.Errorf("%w: accept language contains invalid characters", ErrInvalidRequestOptions)
+	}
+	for name, value := range opts.Header {
+		if !httpguts.ValidHeaderFieldName(name) {
+			return fmt.Errorf("%w: invalid header name %q", ErrInvalidRequestOptions, name)
+		}
+		if option, reserved := reservedHeaders[http.CanonicalHeaderKey(name)]; reserved {
+			if option != "" {
+				return fmt.Errorf("%w: set header %s with %s", ErrInvalidRequestOptions, name, option)
+			}
+			return fmt.Errorf("%w: header %s cannot be set", ErrInvalidRequestOptions, name)
+		}
+		if !httpguts.ValidHeaderFieldValue(value) {
+			return fmt.Errorf("%w: header %s contains invalid characters", ErrInvalidRequestOptions, name)
+		}
+	}
+	for name, value := range opts.Cookies {
+		if err := (&http.Cookie{Name: name, Value: value}).Valid(); err != nil {
+			return fmt.Errorf("%w: cookie %q: %v", ErrInvalidRequestOptions, name, err)
+		}
+	}
+	if opts.Timeout < 0 || opts.Timeout > MaxRequestTimeout {
+		return fmt.Errorf("%w: timeout must be between 0 and %s", ErrInvalidRequestOptions, MaxRequestTimeout)
+	}
+	return nil
+}
+
+// hasRequestSettings reports whether the options change how pages are requested.
+func (opts Options) hasRequestSettings() bool {
+	return opts.UserAgent != "" || len(opts.Header) > 0 || len(opts.Cookies) > 0 ||
+		opts.AcceptLanguage != "" || opts.Timeout > 0
+}
+
+// requestHeaders returns the headers the options add to requests: header is
+// sent to every host, siteHeader, with the extra headers and cookies, only to
+// the crawled site.
+func (opts Options) requestHeaders() (header, siteHeader http.Header) {
+	header = make(http.Header, 2)
+	if opts.UserAgent != "" {
+		header.Set("User-Agent", opts.UserAgent)
+	}
+	if opts.AcceptLanguage != "" {
+		header.Set("Accept-Language", opts.AcceptLanguage)
+	}
+
+	siteHeader = make(http.Header, len(opts.Header)+1)
+	for name, value := range opts.Header {
+		siteHeader.Set(name, value)
+	}
+	if len(opts.Cookies) > 0 {
+		names := make([]string, 0, len(opts.Cookies))
+		for name := range opts.Cookies {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		cookies := make([]string, 0, len(names))
+		for _, name := range names {
+			cookies = append(cookies, (&http.Cookie{Name: name, Value: opts.Cookies[name]}).String())
+		}
+		siteHeader.Set("Cookie", strings.Join(cookies, "; "))
+	}
+	return header, siteHeader
+}
+
+// withRequestSettings returns a copy of the crawler that sends the request
+// settings of the options, with extra headers and cookies limited to siteHost
+// and its subdomains, using a fetcher with the requested timeout when one is
+// set.
+func (c *Crawler) withRequestSettings(opts Options, siteHost string) *Crawler {
+	cc := *c
+	cc.header, cc.siteHeader = opts.requestHeaders()
+	cc.siteHost = siteHost
+	if opts.Timeout > 0 {
+		cfg := *c.cfg
+		cfg.RequestTimeout = opts.Timeout
+		cc.cfg = &cfg
+		cc.fetcher = newFetcher(&cfg)
+	}
+	return &cc
+}
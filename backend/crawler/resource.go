@@ -0,0 +1,162 @@
+package crawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	_ "image/gif"  // register GIF for image.DecodeConfig
+	_ "image/jpeg" // register JPEG for image.DecodeConfig
+	_ "image/png"  // register PNG for image.DecodeConfig
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Resource types of analyzed targets.
+const (
+	ResourceHTML  = "html"
+	ResourcePDF   = "pdf"
+	ResourceImage = "image"
+	ResourceJSON  = "json"
+	ResourceOther = "other"
+)
+
+// PDF metadata patterns. PDFs are only scanned for uncompressed objects, so
+// metadata kept in compressed object streams is not found.
+var (
+	pdfVersionPattern = regexp.MustCompile(`^%PDF-(\d\.\d)`)
+	pdfPagePattern    = regexp.MustCompile(`/Type\s*/Page\b`)
+	pdfTitlePattern   = regexp.MustCompile(`/Title\s*\(((?:[^()\\]|\\.)*)\)`)
+	pdfAuthorPattern  = regexp.MustCompile(`/Author\s*\(((?:[^()\\]|\\.)*)\)`)
+)
+
+// ResourceInfo holds the analysis of a non-HTML target. Only the field
+// matching the resource type is set.
+type ResourceInfo struct {
+	PDF   *PDFInfo   `json:"pdf,omitempty"`
+	Image *ImageInfo `json:"image,omitempty"`
+	JSON  *JSONInfo  `json:"json,omitempty"`
+}
+
+// PDFInfo holds basic metadata of a PDF document.
+type PDFInfo struct {
+	Version   string `json:"version"`
+	Pages     int    `json:"pages"`
+	Title     string `json:"title,omitempty"`
+	Author    string `json:"author,omitempty"`
+	Encrypted bool   `json:"encrypted"`
+}
+
+// ImageInfo holds the format and dimensions of an image. Dimensions are only
+// known for GIF, JPEG and PNG images.
+type ImageInfo struct {
+	Format string `json:"format"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// JSONInfo reports whether a JSON document is valid and the type of its root value.
+type JSONInfo struct {
+	Valid    bool   `json:"valid"`
+	RootType string `json:"root_type,omitempty"` // object, array, string, number, boolean or null
+	Error    string `json:"error,omitempty"`
+}
+
+// detectMediaType returns the media type of a response from its Content-Type
+// header, sniffing the body when the header is missing or generic.
+func detectMediaType(contentType string, body []byte) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType == "" || mediaType == "application/octet-stream" || mediaType == "binary/octet-stream" {
+		mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(body))
+	}
+	return mediaType
+}
+
+// resourceType maps a media type to one of the resource types.
+func resourceType(mediaType string) string {
+	switch {
+	case mediaType == "text/html", mediaType == "application/xhtml+xml":
+		return ResourceHTML
+	case mediaType == "application/pdf":
+		return ResourcePDF
+	case strings.HasPrefix(mediaType, "image/"):
+		return ResourceImage
+	case mediaType == "application/json", strings.HasSuffix(mediaType, "+json"):
+		return ResourceJSON
+	}
+	return ResourceOther
+}
+
+// analyzeResource analyzes the body of a non-HTML target.
+func analyzeResource(kind, mediaType string, body []byte) *ResourceInfo {
+	switch kind {
+	case ResourcePDF:
+		return &ResourceInfo{PDF: analyzePDF(body)}
+	case ResourceImage:
+		return &ResourceInfo{Image: analyzeImage(mediaType, body)}
+	case ResourceJSON:
+		return &ResourceInfo{JSON: analyzeJSON(body)}
+	}
+	return nil
+}
+
+// analyzePDF extracts basic metadata from a PDF document.
+func analyzePDF(body []byte) *PDFInfo {
+	info := &PDFInfo{
+		Pages:     len(pdfPagePattern.FindAllIndex(body, -1)),
+		Encrypted: bytes.Contains(body, []byte("/Encrypt")),
+	}
+	if m := pdfVersionPattern.FindSubmatch(body); m != nil {
+		info.Version = string(m[1])
+	}
+	if m := pdfTitlePattern.FindSubmatch(body); m != nil {
+		info.Title = pdfString(m[1])
+	}
+	if m := pdfAuthorPattern.FindSubmatch(body); m != nil {
+		info.Author = pdfString(m[1])
+	}
+	return info
+}
+
+// pdfString decodes the escapes of a PDF literal string.
+func pdfString(raw []byte) string {
+	s := string(raw)
+	replacer := strings.NewReplacer(`\(`, "(", `\)`, ")", `\\`, `\`, `\n`, "\n", `\r`, "\r", `\t`, "\t")
+	return strings.TrimSpace(replacer.Replace(s))
+}
+
+// analyzeImage reads the format and dimensions of an image.
+func analyzeImage(mediaType string, body []byte) *ImageInfo {
+	info := &ImageInfo{Format: strings.TrimPrefix(mediaType, "image/")}
+	if cfg, format, err := image.DecodeConfig(bytes.NewReader(body)); err == nil {
+		info.Format = format
+		info.Width = cfg.Width
+		info.Height = cfg.Height
+	}
+	return info
+}
+
+// analyzeJSON validates a JSON document.
+func analyzeJSON(body []byte) *JSONInfo {
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return &JSONInfo{Error: err.Error()}
+	}
+	info := &JSONInfo{Valid: true}
+	switch value.(type) {
+	case map[string]any:
+		info.RootType = "object"
+	case []any:
+		info.RootType = "array"
+	case string:
+		info.RootType = "string"
+	case float64:
+		info.RootType = "number"
+	case bool:
+		info.RootType = "boolean"
+	case nil:
+		info.RootType = "null"
+	}
+	return info
+}
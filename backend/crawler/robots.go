@@ -0,0 +1,403 @@
+package crawler
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// UserAgent is the User-Agent sent by the crawler and matched against
+// robots.txt groups.
+const UserAgent = "URLAnalyzerBot/1.0 (+https://github.com/karippery/url_analyzer)"
+
+// robotsProductToken is the user-agent token used to select robots.txt groups.
+const robotsProductToken = "urlanalyzerbot"
+
+// Robots.txt fetching limits.
+const (
+	robotsCacheTTL      = 24 * time.Hour
+	robotsSweepInterval = 10 * time.Minute
+	robotsMaxSize       = 500 * 1024
+	MaxCrawlDelay       = 30 * time.Second
+	robotsFetchWait     = 10 * time.Second
+)
+
+// ErrDisallowedByRobots is returned when robots.txt forbids fetching a URL.
+var ErrDisallowedByRobots = errors.New("disallowed by robots.txt")
+
+// robotsRule is a single Allow or Disallow line.
+type robotsRule struct {
+	pattern string
+	allow   bool
+}
+
+// robotsGroup holds the rules for one set of user agents.
+type robotsGroup struct {
+	agents     []string
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+// Robots is a parsed robots.txt file.
+type Robots struct {
+	groups   []*robotsGroup
+	Sitemaps []string
+
+	allowAll    bool
+	disallowAll bool
+}
+
+// ParseRobots parses the contents of a robots.txt file. Rule patterns are
+// percent-encoded as they are read, to be compared with escaped URL paths.
+func ParseRobots(r io.Reader) *Robots {
+	robots := &Robots{}
+	var current *robotsGroup
+	inAgents := false
+
+	scanner := bufio.NewScanner(io.LimitReader(r, robotsMaxSize))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// Consecutive user-agent lines share one group
+			if current == nil || !inAgents {
+				current = &robotsGroup{}
+				robots.groups = append(robots.groups, current)
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+			inAgents = true
+		case "allow", "disallow":
+			inAgents = false
+			if current == nil {
+				continue
+			}
+			// An empty Disallow allows everything and carries no rule
+			if value == "" {
+				continue
+			}
+			current.rules = append(current.rules, robotsRule{pattern: escapeRobotsPath(value), allow: key == "allow"})
+		case "crawl-delay":
+			inAgents = false
+			if current == nil {
+				continue
+			}
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				current.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		case "sitemap":
+			if value != "" {
+				robots.Sitemaps = append(robots.Sitemaps, value)
+			}
+		default:
+			inAgents = false
+		}
+	}
+	return robots
+}
+
+// group returns the group that applies to the given user-agent token, preferring
+// the most specific matching agent and falling back to the "*" group.
+func (r *Robots) group(token string) *robotsGroup {
+	var best, wildcard *robotsGroup
+	bestLen := 0
+	for _, g := range r.groups {
+		for _, agent := range g.agents {
+			if agent == "*" {
+				if wildcard == nil {
+					wildcard = g
+				}
+				continue
+			}
+			if strings.Contains(token, agent) && len(agent) > bestLen {
+				best, bestLen = g, len(agent)
+			}
+		}
+	}
+	if best != nil {
+		return best
+	}
+	return wildcard
+}
+
+// Allowed reports whether the crawler may fetch the given URL path. The longest
+// matching rule wins and Allow wins ties with Disallow.
+func (r *Robots) Allowed(u *url.URL) bool {
+	if r.allowAll {
+		return true
+	}
+	if r.disallowAll {
+		return false
+	}
+	g := r.group(robotsProductToken)
+	if g == nil {
+		return true
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	path = escapeRobotsPath(path)
+
+	matched, matchLen, allowed := false, -1, true
+	for _, rule := range g.rules {
+		if !robotsPatternMatch(rule.pattern, path) {
+			continue
+		}
+		n := len(rule.pattern)
+		if n > matchLen || (n == matchLen && rule.allow && !allowed) {
+			matched, matchLen, allowed = true, n, rule.allow
+		}
+	}
+	return !matched || allowed
+}
+
+// CrawlDelay returns the Crawl-delay for the crawler's group, capped at MaxCrawlDelay.
+func (r *Robots) CrawlDelay() time.Duration {
+	g := r.group(robotsProductToken)
+	if g == nil {
+		return 0
+	}
+	if g.crawlDelay > MaxCrawlDelay {
+		return MaxCrawlDelay
+	}
+	return g.crawlDelay
+}
+
+// escapeRobotsPath percent-encodes the non-ASCII bytes, spaces and control
+// characters of a robots.txt pattern or URL path and upper-cases existing
+// escapes, so that rules written with raw UTF-8 match escaped URL paths.
+func escapeRobotsPath(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c <= ' ' || c >= 0x7f:
+			fmt.Fprintf(&b, "%%%02X", c)
+		case c == '%' && i+2 < len(s) && isHexDigit(s[i+1]) && isHexDigit(s[i+2]):
+			b.WriteString(strings.ToUpper(s[i : i+3]))
+			i += 2
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// isHexDigit reports whether c is a hexadecimal digit.
+func isHexDigit(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+// robotsPatternMatch matches a path against a robots.txt pattern supporting
+// the "*" wildcard and the "$" end anchor. Both are in escapeRobotsPath form.
+func robotsPatternMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = strings.TrimSuffix(pattern, "$")
+	}
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	pos := len(parts[0])
+	last := len(parts) - 1
+	for i, part := range parts[1:] {
+		if anchored && i+1 == last {
+			// With an anchor the final literal part must end the path
+			return len(path)-len(part) >= pos && strings.HasSuffix(path, part)
+		}
+		j := strings.Index(path[pos:], part)
+		if j < 0 {
+			return false
+		}
+		pos += j + len(part)
+	}
+	return !anchored || pos == len(path)
+}
+
+// robotsEntry is a cached robots.txt for a single host. ready is closed once
+// the fetch has finished; robots stays nil while it runs and when it was
+// cancelled.
+type robotsEntry struct {
+	robots    *Robots
+	fetchedAt time.Time
+	ready     chan struct{}
+}
+
+// expired reports whether a fetched entry is older than robotsCacheTTL.
+func (e *robotsEntry) expired(now time.Time) bool {
+	return e.robots != nil && now.Sub(e.fetchedAt) >= robotsCacheTTL
+}
+
+// robotsCache fetches and caches robots.txt per scheme and host, and tracks
+// the last request time per host to enforce Crawl-delay. Concurrent lookups
+// of the same host share a single fetch, and stale entries are swept
+// periodically.
+type robotsCache struct {
+	do func(ctx context.Context, method, rawURL string) (*http.Response, error)
+
+	mu          sync.Mutex
+	entries     map[string]*robotsEntry
+	lastRequest map[string]time.Time
+	lastSweep   time.Time
+}
+
+// newRobotsCache creates an empty robots.txt cache that fetches files with do.
+func newRobotsCache(do func(ctx context.Context, method, rawURL string) (*http.Response, error)) *robotsCache {
+	return &robotsCache{
+		do:          do,
+		entries:     make(map[string]*robotsEntry),
+		lastRequest: make(map[string]time.Time),
+	}
+}
+
+// get returns the robots.txt for the URL's host, fetching it if needed or
+// waiting for a fetch already in progress.
+func (rc *robotsCache) get(ctx context.Context, u *url.URL) *Robots {
+	key := u.Scheme + "://" + u.Host
+
+	for {
+		rc.mu.Lock()
+		entry, ok := rc.entries[key]
+		if !ok || entry.expired(time.Now()) {
+			entry = &robotsEntry{ready: make(chan struct{})}
+			rc.entries[key] = entry
+			rc.sweep()
+			rc.mu.Unlock()
+			return rc.load(ctx, key, entry)
+		}
+		rc.mu.Unlock()
+
+		select {
+		case <-entry.ready:
+		case <-ctx.Done():
+			return &Robots{allowAll: true}
+		}
+		if entry.robots != nil {
+			return entry.robots
+		}
+		// The fetch was cancelled by its caller, so try again with this context
+	}
+}
+
+// load fetches robots.txt into entry and wakes up callers waiting for it.
+func (rc *robotsCache) load(ctx context.Context, key string, entry *robotsEntry) *Robots {
+	robots := rc.fetch(ctx, key+"/robots.txt")
+
+	rc.mu.Lock()
+	if ctx.Err() != nil {
+		// A cancelled fetch says nothing about the host, so don't cache it
+		if rc.entries[key] == entry {
+			delete(rc.entries, key)
+		}
+	} else {
+		entry.robots, entry.fetchedAt = robots, time.Now()
+	}
+	rc.mu.Unlock()
+	close(entry.ready)
+	return robots
+}
+
+// sweep drops expired robots.txt files and request times too old to delay
+// any further request, at most once per robotsSweepInterval. Callers must
+// hold rc.mu.
+func (rc *robotsCache) sweep() {
+	now := time.Now()
+	if now.Sub(rc.lastSweep) < robotsSweepInterval {
+		return
+	}
+	rc.lastSweep = now
+	for key, entry := range rc.entries {
+		if entry.expired(now) {
+			delete(rc.entries, key)
+		}
+	}
+	for host, last := range rc.lastRequest {
+		if now.Sub(last) > MaxCrawlDelay {
+			delete(rc.lastRequest, host)
+		}
+	}
+}
+
+// fetch downloads and parses a robots.txt file. A missing file (4xx) allows
+// everything and server errors (5xx) disallow everything. Network errors allow
+// everything so that unreachable hosts are reported as broken rather than skipped.
+func (rc *robotsCache) fetch(ctx context.Context, robotsURL string) *Robots {
+	ctx, cancel := context.WithTimeout(ctx, robotsFetchWait)
+	defer cancel()
+
+	resp, err := rc.do(ctx, http.MethodGet, robotsURL)
+	if err != nil {
+		return &Robots{allowAll: true}
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		return &Robots{disallowAll: true}
+	case resp.StatusCode != http.StatusOK:
+		return &Robots{allowAll: true}
+	}
+	return ParseRobots(resp.Body)
+}
+
+// allowed reports whether rawURL may be fetched according to its host's robots.txt.
+func (rc *robotsCache) allowed(ctx context.Context, rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return true
+	}
+	return rc.get(ctx, u).Allowed(u)
+}
+
+// wait blocks until the host's Crawl-delay has elapsed since the previous
+// request to it, then records the new request time. It returns early with the
+// context's error if the context is done first.
+func (rc *robotsCache) wait(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return nil
+	}
+	delay := rc.get(ctx, u).CrawlDelay()
+
+	rc.mu.Lock()
+	next := rc.lastRequest[u.Host].Add(delay)
+	now := time.Now()
+	if next.Before(now) {
+		next = now
+	}
+	rc.lastRequest[u.Host] = next
+	rc.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(next))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,58 @@
+package crawler
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRobotsAllowed(t *testing.T) {
+	tests := []struct {
+		name   string
+		robots string
+		path   string
+		want   bool
+	}{
+		{"no rules", "User-agent: *\n", "/page", true},
+		{"disallowed prefix", "User-agent: *\nDisallow: /private", "/private/page", false},
+		{"empty disallow", "User-agent: *\nDisallow:", "/page", true},
+		{"longer allow wins", "User-agent: *\nDisallow: /shop\nAllow: /shop/public", "/shop/public/item", true},
+		{"longer disallow wins", "User-agent: *\nAllow: /shop\nDisallow: /shop/cart", "/shop/cart", false},
+		{"allow wins tie", "User-agent: *\nDisallow: /page\nAllow: /page", "/page", true},
+		{"wildcard", "User-agent: *\nDisallow: /*.pdf", "/files/report.pdf", false},
+		{"wildcard no match", "User-agent: *\nDisallow: /*.pdf", "/files/report.html", true},
+		{"anchor matches end", "User-agent: *\nDisallow: /*.php$", "/index.php", false},
+		{"anchor rejects longer path", "User-agent: *\nDisallow: /*.php$", "/index.php/extra", true},
+		{"query", "User-agent: *\nDisallow: /*?sort=", "/list?sort=asc", false},
+		{"specific group", "User-agent: *\nDisallow: /\n\nUser-agent: URLAnalyzerBot\nDisallow: /private", "/page", true},
+		{"specific group rules", "User-agent: *\nDisallow: /\n\nUser-agent: URLAnalyzerBot\nDisallow: /private", "/private", false},
+		{"other bot group", "User-agent: OtherBot\nDisallow: /", "/page", true},
+		{"shared group", "User-agent: OtherBot\nUser-agent: urlanalyzerbot\nDisallow: /", "/page", false},
+		{"non-ASCII rule", "User-agent: *\nDisallow: /café", "/café", false},
+		{"escaped rule", "User-agent: *\nDisallow: /caf%c3%a9", "/café", false},
+		{"non-ASCII allow", "User-agent: *\nDisallow: /\nAllow: /über", "/über/uns", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse("https://example.com" + tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := ParseRobots(strings.NewReader(tt.robots)).Allowed(u); got != tt.want {
+				t.Errorf("Allowed(%s) = %t, want %t", u.EscapedPath(), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRobotsCrawlDelay(t *testing.T) {
+	robots := ParseRobots(strings.NewReader("User-agent: *\nCrawl-delay: 2.5\n\nUser-agent: SlowBot\nCrawl-delay: 600"))
+	if got := robots.CrawlDelay(); got != 2500*time.Millisecond {
+		t.Errorf("CrawlDelay = %s, want 2.5s from the * group", got)
+	}
+	robots = ParseRobots(strings.NewReader("User-agent: URLAnalyzerBot\nCrawl-delay: 600"))
+	if got := robots.CrawlDelay(); got != MaxCrawlDelay {
+		t.Errorf("CrawlDelay = %s, want it capped at %s", got, MaxCrawlDelay)
+	}
+}
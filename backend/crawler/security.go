@@ -0,0 +1,276 @@
+package crawler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Security header verdicts.
+const (
+	VerdictPass = "pass"
+	VerdictWarn = "warn"
+	VerdictFail = "fail"
+)
+
+// minHSTSMaxAge is the smallest HSTS max-age (180 days) graded as a pass.
+const minHSTSMaxAge = 180 * 24 * 60 * 60
+
+// HeaderVerdict is the grading of a single security header.
+type HeaderVerdict struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	Present bool   `json:"present"`
+	Verdict string `json:"verdict"`
+	Message string `json:"message"`
+	weight  int
+}
+
+// SecurityHeaders holds the graded security headers of a response.
+type SecurityHeaders struct {
+	Grade   string              `json:"grade"`
+	Score   int                 `json:"score"` // 0-100
+	Headers []HeaderVerdict     `json:"headers"`
+	CSP     map[string][]string `json:"csp,omitempty"` // Content-Security-Policy directives
+}
+
+// analyzeSecurityHeaders grades the security-relevant response headers. https
+// reports whether the page was served over HTTPS, which HSTS depends on.
+func analyzeSecurityHeaders(header http.Header, https bool) *SecurityHeaders {
+	sec := &SecurityHeaders{}
+
+	csp := header.Get("Content-Security-Policy")
+	if csp != "" {
+		sec.CSP = parseCSP(csp)
+	}
+
+	sec.Headers = []HeaderVerdict{
+		gradeCSP(csp, sec.CSP, header.Get("Content-Security-Policy-Report-Only")),
+		gradeHSTS(header.Get("Strict-Transport-Security"), https),
+		gradeFrameOptions(header.Get("X-Frame-Options"), sec.CSP),
+		gradeContentTypeOptions(header.Get("X-Content-Type-Options")),
+		gradeReferrerPolicy(header.Get("Referrer-Policy")),
+		gradePermissionsPolicy(header.Get("Permissions-Policy")),
+		gradeCOOP(header.Get("Cross-Origin-Opener-Policy")),
+		gradeCOEP(header.Get("Cross-Origin-Embedder-Policy")),
+	}
+
+	total, earned := 0, 0
+	for _, h := range sec.Headers {
+		total += h.weight
+		switch h.Verdict {
+		case VerdictPass:
+			earned += h.weight * 2
+		case VerdictWarn:
+			earned += h.weight
+		}
+	}
+	sec.Score = earned * 100 / (total * 2)
+	sec.Grade = securityGrade(sec.Score)
+	return sec
+}
+
+// flattenHeader joins the values of every response header, keyed by the
+// canonical header name.
+func flattenHeader(header http.Header) map[string]string {
+	flat := make(map[string]string, len(header))
+	for name, values := range header {
+		flat[http.CanonicalHeaderKey(name)] = strings.Join(values, ", ")
+	}
+	return flat
+}
+
+// parseCSP parses a Content-Security-Policy into its directives. Only the
+// first occurrence of a directive counts, as browsers ignore repeats.
+func parseCSP(policy string) map[string][]string {
+	directives := make(map[string][]string)
+	for _, part := range strings.Split(policy, ";") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		name := strings.ToLower(fields[0])
+		if _, seen := directives[name]; seen {
+			continue
+		}
+		directives[name] = fields[1:]
+	}
+	return directives
+}
+
+// securityGrade maps a score to a letter grade.
+func securityGrade(score int) string {
+	switch {
+	case score >= 90:
+		return "A"
+	case score >= 75:
+		return "B"
+	case score >= 60:
+		return "C"
+	case score >= 45:
+		return "D"
+	default:
+		return "F"
+	}
+}
+
+// verdict builds a HeaderVerdict.
+func verdict(name, value string, weight int, result, message string) HeaderVerdict {
+	return HeaderVerdict{Name: name, Value: value, Present: value != "", Verdict: result, Message: message, weight: weight}
+}
+
+// gradeCSP grades the Content-Security-Policy header.
+func gradeCSP(value string, directives map[string][]string, reportOnly string) HeaderVerdict {
+	const name, weight = "Content-Security-Policy", 25
+	if value == "" {
+		if reportOnly != "" {
+			return verdict(name, value, weight, VerdictFail, "only a report-only policy is set, nothing is enforced")
+		}
+		return verdict(name, value, weight, VerdictFail, "header is missing")
+	}
+
+	sources, ok := directives["script-src"]
+	if !ok {
+		sources, ok = directives["default-src"]
+	}
+	if !ok {
+		return verdict(name, value, weight, VerdictWarn, "policy has neither script-src nor default-src")
+	}
+
+	var problems []string
+	hasNonceOrHash := false
+	for _, src := range sources {
+		lower := strings.ToLower(src)
+		if strings.HasPrefix(lower, "'nonce-") || strings.HasPrefix(lower, "'sha") {
+			hasNonceOrHash = true
+		}
+	}
+	for _, src := range sources {
+		switch strings.ToLower(src) {
+		case "'unsafe-inline'":
+			// Browsers ignore unsafe-inline when a nonce or hash is present
+			if !hasNonceOrHash {
+				problems = append(problems, "allows 'unsafe-inline' scripts")
+			}
+		case "'unsafe-eval'":
+			problems = append(problems, "allows 'unsafe-eval'")
+		case "*", "http:", "https:", "data:":
+			problems = append(problems, "allows scripts from "+src)
+		}
+	}
+	if objects, ok := directives["object-src"]; ok && !(len(objects) == 1 && objects[0] == "'none'") {
+		problems = append(problems, "object-src is not 'none'")
+	}
+	if len(problems) > 0 {
+		return verdict(name, value, weight, VerdictWarn, "policy "+strings.Join(problems, ", "))
+	}
+	return verdict(name, value, weight, VerdictPass, "policy restricts script sources")
+}
+
+// gradeHSTS grades the Strict-Transport-Security header.
+func gradeHSTS(value string, https bool) HeaderVerdict {
+	const name, weight = "Strict-Transport-Security", 20
+	if !https {
+		return verdict(name, value, weight, VerdictFail, "page is served over plain HTTP")
+	}
+	if value == "" {
+		return verdict(name, value, weight, VerdictFail, "header is missing")
+	}
+
+	maxAge := -1
+	for _, part := range strings.Split(value, ";") {
+		key, val, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if strings.EqualFold(key, "max-age") {
+			if n, err := strconv.Atoi(strings.Trim(val, `"`)); err == nil {
+				maxAge = n
+			}
+		}
+	}
+	switch {
+	case maxAge < 0:
+		return verdict(name, value, weight, VerdictFail, "max-age is missing or invalid")
+	case maxAge < minHSTSMaxAge:
+		return verdict(name, value, weight, VerdictWarn, "max-age is shorter than 180 days")
+	}
+	return verdict(name, value, weight, VerdictPass, "HTTPS is enforced")
+}
+
+// gradeFrameOptions grades X-Frame-Options, accepting CSP frame-ancestors instead.
+func gradeFrameOptions(value string, csp map[string][]string) HeaderVerdict {
+	const name, weight = "X-Frame-Options", 15
+	switch strings.ToUpper(strings.TrimSpace(value)) {
+	case "DENY", "SAMEORIGIN":
+		return verdict(name, value, weight, VerdictPass, "framing is restricted")
+	case "":
+		if _, ok := csp["frame-ancestors"]; ok {
+			return verdict(name, value, weight, VerdictPass, "framing is restricted by CSP frame-ancestors")
+		}
+		return verdict(name, value, weight, VerdictFail, "header is missing; page can be framed (clickjacking)")
+	}
+	return verdict(name, value, weight, VerdictWarn, "unsupported value")
+}
+
+// gradeContentTypeOptions grades X-Content-Type-Options.
+func gradeContentTypeOptions(value string) HeaderVerdict {
+	const name, weight = "X-Content-Type-Options", 15
+	switch {
+	case strings.EqualFold(strings.TrimSpace(value), "nosniff"):
+		return verdict(name, value, weight, VerdictPass, "MIME sniffing is disabled")
+	case value == "":
+		return verdict(name, value, weight, VerdictFail, "header is missing")
+	}
+	return verdict(name, value, weight, VerdictFail, "value must be nosniff")
+}
+
+// gradeReferrerPolicy grades Referrer-Policy. The last recognized policy in a
+// comma-separated list is the one browsers apply.
+func gradeReferrerPolicy(value string) HeaderVerdict {
+	const name, weight = "Referrer-Policy", 10
+	if value == "" {
+		return verdict(name, value, weight, VerdictWarn, "header is missing; browser default applies")
+	}
+	policies := strings.Split(value, ",")
+	policy := strings.ToLower(strings.TrimSpace(policies[len(policies)-1]))
+	switch policy {
+	case "no-referrer", "same-origin", "strict-origin", "strict-origin-when-cross-origin":
+		return verdict(name, value, weight, VerdictPass, "referrer leakage is limited")
+	case "origin", "origin-when-cross-origin", "no-referrer-when-downgrade":
+		return verdict(name, value, weight, VerdictWarn, policy+" may leak referrer information")
+	case "unsafe-url":
+		return verdict(name, value, weight, VerdictFail, "unsafe-url sends the full URL to every origin")
+	}
+	return verdict(name, value, weight, VerdictWarn, "unrecognized policy")
+}
+
+// gradePermissionsPolicy grades Permissions-Policy.
+func gradePermissionsPolicy(value string) HeaderVerdict {
+	const name, weight = "Permissions-Policy", 5
+	if value == "" {
+		return verdict(name, value, weight, VerdictWarn, "header is missing")
+	}
+	return verdict(name, value, weight, VerdictPass, "browser features are restricted")
+}
+
+// gradeCOOP grades Cross-Origin-Opener-Policy.
+func gradeCOOP(value string) HeaderVerdict {
+	const name, weight = "Cross-Origin-Opener-Policy", 5
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "same-origin", "same-origin-allow-popups":
+		return verdict(name, value, weight, VerdictPass, "browsing context is isolated")
+	case "":
+		return verdict(name, value, weight, VerdictWarn, "header is missing")
+	}
+	return verdict(name, value, weight, VerdictWarn, "browsing context is not isolated")
+}
+
+// gradeCOEP grades Cross-Origin-Embedder-Policy.
+func gradeCOEP(value string) HeaderVerdict {
+	const name, weight = "Cross-Origin-Embedder-Policy", 5
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "require-corp", "credentialless":
+		return verdict(name, value, weight, VerdictPass, "cross-origin resources must opt in")
+	case "":
+		return verdict(name, value, weight, VerdictWarn, "header is missing")
+	}
+	return verdict(name, value, weight, VerdictWarn, "cross-origin resources are not restricted")
+}
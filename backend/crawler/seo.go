@@ -0,0 +1,202 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// SEO length limits used for findings.
+const (
+	maxTitleLength       = 60
+	maxDescriptionLength = 160
+)
+
+// SEO finding codes.
+const (
+	SEOMissingTitle       = "missing_title"
+	SEOTitleTooLong       = "title_too_long"
+	SEOMissingDescription = "missing_description"
+	SEODescriptionTooLong = "description_too_long"
+	SEOMultipleCanonicals = "multiple_canonicals"
+	SEOCanonicalElsewhere = "canonical_points_elsewhere"
+	SEONoIndex            = "noindex"
+)
+
+// HreflangLink is an alternate language version of the page.
+type HreflangLink struct {
+	Lang string `json:"lang"`
+	URL  string `json:"url"`
+}
+
+// SEOData holds the search engine metadata of a page.
+type SEOData struct {
+	TitleLength     int               `json:"title_length"`
+	MetaDescription string            `json:"meta_description"`
+	MetaRobots      string            `json:"meta_robots"`
+	Canonicals      []string          `json:"canonicals"`
+	OpenGraph       map[string]string `json:"open_graph"`
+	TwitterCard     map[string]string `json:"twitter_card"`
+	Hreflang        []HreflangLink    `json:"hreflang"`
+	Findings        []Finding         `json:"findings"`
+}
+
+// analyzeSEO extracts SEO metadata from the document and reports findings.
+// Canonical and hreflang URLs are resolved against baseURL and compared to pageURL.
+func analyzeSEO(doc *html.Node, pageURL, baseURL string) *SEOData {
+	seo := &SEOData{
+		OpenGraph:   make(map[string]string),
+		TwitterCard: make(map[string]string),
+	}
+	hasTitle := false
+
+	var traverse func(*html.Node)
+	traverse = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "title":
+				if !hasTitle {
+					hasTitle = true
+					seo.TitleLength = utf8.RuneCountInString(strings.TrimSpace(textContent(n)))
+				}
+			case "meta":
+				seo.addMeta(n)
+			case "link":
+				seo.addLink(n, baseURL)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			traverse(c)
+		}
+	}
+	traverse(doc)
+
+	seo.Findings = seoFindings(seo, hasTitle, pageURL)
+	return seo
+}
+
+// addMeta records a <meta> element relevant to SEO.
+func (s *SEOData) addMeta(n *html.Node) {
+	name := strings.ToLower(getAttr(n, "name"))
+	property := strings.ToLower(getAttr(n, "property"))
+	content := strings.TrimSpace(getAttr(n, "content"))
+
+	switch {
+	case name == "description":
+		if s.MetaDescription == "" {
+			s.MetaDescription = content
+		}
+	case name == "robots":
+		s.MetaRobots = content
+	case strings.HasPrefix(property, "og:"):
+		s.OpenGraph[strings.TrimPrefix(property, "og:")] = content
+	case strings.HasPrefix(name, "twitter:"):
+		s.TwitterCard[strings.TrimPrefix(name, "twitter:")] = content
+	case strings.HasPrefix(property, "twitter:"):
+		// Some sites use property instead of name for Twitter Cards
+		s.TwitterCard[strings.TrimPrefix(property, "twitter:")] = content
+	}
+}
+
+// addLink records canonical and hreflang <link> elements.
+func (s *SEOData) addLink(n *html.Node, baseURL string) {
+	rels := strings.Fields(strings.ToLower(getAttr(n, "rel")))
+	href := strings.TrimSpace(getAttr(n, "href"))
+	for _, rel := range rels {
+		switch rel {
+		case "canonical":
+			s.Canonicals = append(s.Canonicals, resolveURL(baseURL, href))
+		case "alternate":
+			if lang := getAttr(n, "hreflang"); lang != "" {
+				s.Hreflang = append(s.Hreflang, HreflangLink{Lang: lang, URL: resolveURL(baseURL, href)})
+			}
+		}
+	}
+}
+
+// seoFindings reports problems with the extracted SEO metadata.
+func seoFindings(seo *SEOData, hasTitle bool, pageURL string) []Finding {
+	var findings []Finding
+	add := func(code, severity, message string) {
+		findings = append(findings, Finding{Code: code, Severity: severity, Message: message})
+	}
+
+	switch {
+	case !hasTitle || seo.TitleLength == 0:
+		add(SEOMissingTitle, SeverityError, "page has no title")
+	case seo.TitleLength > maxTitleLength:
+		add(SEOTitleTooLong, SeverityWarning,
+			fmt.Sprintf("title is %d characters, longer than %d", seo.TitleLength, maxTitleLength))
+	}
+
+	switch n := utf8.RuneCountInString(seo.MetaDescription); {
+	case n == 0:
+		add(SEOMissingDescription, SeverityWarning, "page has no meta description")
+	case n > maxDescriptionLength:
+		add(SEODescriptionTooLong, SeverityInfo,
+			fmt.Sprintf("meta description is %d characters, longer than %d", n, maxDescriptionLength))
+	}
+
+	if len(seo.Canonicals) > 1 {
+		add(SEOMultipleCanonicals, SeverityError, fmt.Sprintf("page declares %d canonical links", len(seo.Canonicals)))
+	}
+	if len(seo.Canonicals) > 0 && !sameDocument(seo.Canonicals[0], pageURL) {
+		add(SEOCanonicalElsewhere, SeverityWarning, "canonical link points to "+seo.Canonicals[0])
+	}
+
+	if strings.Contains(strings.ToLower(seo.MetaRobots), "noindex") {
+		add(SEONoIndex, SeverityInfo, "meta robots prevents indexing")
+	}
+	return findings
+}
+
+// resolveURL resolves a reference against a base URL, returning the reference
+// unchanged when either cannot be parsed.
+func resolveURL(baseURL, ref string) string {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return ref
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return base.ResolveReference(u).String()
+}
+
+// sameDocument reports whether two URLs point to the same document, ignoring
+// fragments, host case and an empty path versus "/".
+func sameDocument(a, b string) bool {
+	ua, errA := url.Parse(a)
+	ub, errB := url.Parse(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	for _, u := range []*url.URL{ua, ub} {
+		u.Fragment = ""
+		u.Host = strings.ToLower(u.Host)
+		if u.Path == "" {
+			u.Path = "/"
+		}
+	}
+	return ua.String() == ub.String()
+}
+
+// textContent returns the concatenated text of a node and its descendants.
+func textContent(n *html.Node) string {
+	var sb strings.Builder
+	var traverse func(*html.Node)
+	traverse = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			traverse(c)
+		}
+	}
+	traverse(n)
+	return sb.String()
+}
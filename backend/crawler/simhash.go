@@ -0,0 +1,43 @@
+package crawler
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+)
+
+// shingleSize is the number of consecutive words hashed together.
+const shingleSize = 3
+
+// simHash returns the 64-bit SimHash of a text as 16 hex digits, built from
+// overlapping shingles of lowercased words so that texts sharing most of
+// their wording get fingerprints that differ in few bits. Texts shorter than
+// one shingle get no fingerprint.
+func simHash(text string) string {
+	words := splitWords(strings.ToLower(text))
+	if len(words) < shingleSize {
+		return ""
+	}
+
+	var weights [64]int
+	for i := 0; i+shingleSize <= len(words); i++ {
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(words[i:i+shingleSize], " ")))
+		sum := h.Sum64()
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	var fingerprint uint64
+	for bit, weight := range weights {
+		if weight > 0 {
+			fingerprint |= 1 << bit
+		}
+	}
+	return fmt.Sprintf("%016x", fingerprint)
+}
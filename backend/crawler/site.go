@@ -0,0 +1,125 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Site crawl limits applied to requests that follow internal links.
+const (
+	DefaultSitePages = 50
+	MaxSiteDepth     = 5
+	MaxSitePages     = 500
+)
+
+// ErrOffSite is reported for pages of a site crawl that redirect to another host.
+var ErrOffSite = errors.New("redirected off site")
+
+// SiteOptions controls how far a site crawl follows internal links.
+type SiteOptions struct {
+	Options
+	MaxDepth int // Link depth to follow from the start URL; 0 crawls only the start page
+	MaxPages int // Maximum number of pages to analyze successfully
+}
+
+// SitePage is a single page visited during a site crawl.
+type SitePage struct {
+	URL   string
+	Data  *CrawlData
+	Depth int
+	Err   error
+}
+
+// CrawlSite walks a site breadth-first from startURL, following internal
+// links up to the configured depth and page limits. The crawl stays on the
+// host the start page is served from: only links to that host are followed,
+// and pages that redirect elsewhere are reported to visit with ErrOffSite.
+// Pages disallowed by robots.txt are reported to visit with
+// ErrDisallowedByRobots. visit is called for every page in BFS order;
+// returning an error from visit stops the crawl.
+func (c *Crawler) CrawlSite(ctx context.Context, startURL string, opts SiteOptions, visit func(*SitePage) error) error {
+	opts = normalizeSiteOptions(opts)
+
+	type queued struct {
+		url   string
+		depth int
+	}
+
+	start, ok := resolveCrawlableURL(startURL, "")
+	if !ok {
+		start = startURL
+	}
+	queue := []queued{{url: start, depth: 0}}
+	// Pages are tracked by normalized URL, so that links to a page that was
+	// reached through a redirect or written differently are not crawled again
+	seen := map[string]bool{NormalizeURL(start): true}
+	siteHost := urlHost(start)
+	visited := 0
+
+	for len(queue) > 0 && visited < opts.MaxPages {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		next := queue[0]
+		queue = queue[1:]
+
+		data, err := c.Crawl(ctx, next.url, opts.Options)
+		if err == nil {
+			// A start page redirecting to another host, like www., moves the site there
+			if next.depth == 0 {
+				siteHost = urlHost(data.FinalURL)
+			} else if urlHost(data.FinalURL) != siteHost {
+				data, err = nil, fmt.Errorf("%w: %s redirects to %s", ErrOffSite, next.url, data.FinalURL)
+			}
+		}
+		if err == nil {
+			visited++
+			seen[NormalizeURL(data.FinalURL)] = true
+		}
+		if err := visit(&SitePage{URL: next.url, Data: data, Depth: next.depth, Err: err}); err != nil {
+			return err
+		}
+		if err != nil || next.depth >= opts.MaxDepth {
+			continue
+		}
+
+		for _, link := range data.InternalURLs {
+			key := NormalizeURL(link)
+			if seen[key] || urlHost(link) != siteHost {
+				continue
+			}
+			seen[key] = true
+			queue = append(queue, queued{url: link, depth: next.depth + 1})
+		}
+	}
+	return nil
+}
+
+// normalizeSiteOptions clamps site options to the supported limits.
+func normalizeSiteOptions(opts SiteOptions) SiteOptions {
+	if opts.MaxDepth < 0 {
+		opts.MaxDepth = 0
+	}
+	if opts.MaxDepth > MaxSiteDepth {
+		opts.MaxDepth = MaxSiteDepth
+	}
+	if opts.MaxPages <= 0 {
+		opts.MaxPages = DefaultSitePages
+	}
+	if opts.MaxPages > MaxSitePages {
+		opts.MaxPages = MaxSitePages
+	}
+	return opts
+}
+
+// urlHost returns the lowercased host, with any port, of a URL.
+func urlHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Host)
+}
@@ -0,0 +1,301 @@
+package crawler
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sitemap limits. MaxSitemapEntries is the per-file limit from the sitemaps
+// protocol; MaxSitemapURLs is the number of URLs collected per import.
+const (
+	MaxSitemapEntries    = 50000
+	MaxSitemapURLs       = 1000
+	maxSitemapFiles      = 100
+	maxSitemapIndexDepth = 3
+	maxSitemapSize       = 50 * 1024 * 1024
+	sitemapCheckWorkers  = 8
+)
+
+// Sitemap issue types.
+const (
+	SitemapIssueFetch       = "fetch_error"
+	SitemapIssueParse       = "parse_error"
+	SitemapIssueTooLarge    = "too_many_entries"
+	SitemapIssueURLLimit    = "url_limit_reached"
+	SitemapIssueFutureMod   = "lastmod_in_future"
+	SitemapIssueStatus      = "non_200_status"
+	SitemapIssueDisallowed  = "robots_disallowed"
+	SitemapIssueInvalidLink = "invalid_url"
+)
+
+// SitemapURL is a single page listed in a urlset.
+type SitemapURL struct {
+	Loc     string `json:"loc"`
+	LastMod string `json:"lastmod,omitempty"`
+	Sitemap string `json:"sitemap"`
+}
+
+// SitemapIssue is a problem found while reading a sitemap or checking its URLs.
+type SitemapIssue struct {
+	Type    string `json:"type"`
+	Sitemap string `json:"sitemap"`
+	URL     string `json:"url,omitempty"`
+	Message string `json:"message"`
+}
+
+// SitemapReport is the outcome of reading one or more sitemaps.
+type SitemapReport struct {
+	Sitemaps []string       `json:"sitemaps"`
+	URLs     []SitemapURL   `json:"urls"`
+	Issues   []SitemapIssue `json:"issues"`
+	Checked  int            `json:"checked"` // URLs whose status was checked
+}
+
+// SitemapOptions controls sitemap ingestion.
+type SitemapOptions struct {
+	Options
+	CheckStatus bool // Check every listed URL like a link and report non-200 responses, within the link check timeout
+}
+
+// sitemapDocument covers both <urlset> and <sitemapindex> documents.
+type sitemapDocument struct {
+	XMLName  xml.Name     `xml:""`
+	URLs     []sitemapLoc `xml:"url"`
+	Sitemaps []sitemapLoc `xml:"sitemap"`
+}
+
+// sitemapLoc is a <url> or <sitemap> entry.
+type sitemapLoc struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+// IsSitemapURL reports whether rawURL looks like a sitemap file rather than a site.
+func IsSitemapURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	path := strings.ToLower(u.Path)
+	return strings.HasSuffix(path, ".xml") || strings.HasSuffix(path, ".xml.gz")
+}
+
+// DiscoverSitemaps returns the sitemaps declared in the site's robots.txt,
+// falling back to /sitemap.xml when none are declared.
+func (c *Crawler) DiscoverSitemaps(ctx context.Context, siteURL string) ([]string, error) {
+	u, err := url.ParseRequestURI(siteURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	if sitemaps := c.robots.get(ctx, u).Sitemaps; len(sitemaps) > 0 {
+		return sitemaps, nil
+	}
+	return []string{u.Scheme + "://" + u.Host + "/sitemap.xml"}, nil
+}
+
+// ReadSitemaps fetches the given sitemaps, following sitemap indexes, and
+// returns the listed URLs together with any problems found. Reading stops
+// once MaxSitemapURLs URLs have been collected, and only the first
+// MaxSitemapEntries entries of an oversized sitemap are used.
+func (c *Crawler) ReadSitemaps(ctx context.Context, sitemaps []string, opts SitemapOptions) *SitemapReport {
+	report := &SitemapReport{}
+	seen := make(map[string]bool)
+	seenURLs := make(map[string]bool)
+
+	var read func(sitemapURL string, depth int)
+	read = func(sitemapURL string, depth int) {
+		if seen[sitemapURL] || len(report.Sitemaps) >= maxSitemapFiles || len(report.URLs) >= MaxSitemapURLs || ctx.Err() != nil {
+			return
+		}
+		seen[sitemapURL] = true
+		report.Sitemaps = append(report.Sitemaps, sitemapURL)
+
+		doc, err := c.fetchSitemap(ctx, sitemapURL, opts.Options)
+		if err != nil {
+			report.addIssue(SitemapIssueFetch, sitemapURL, "", err.Error())
+			return
+		}
+
+		entries := len(doc.URLs) + len(doc.Sitemaps)
+		if entries > MaxSitemapEntries {
+			report.addIssue(SitemapIssueTooLarge, sitemapURL, "",
+				fmt.Sprintf("sitemap lists %d entries, more than the %d allowed", entries, MaxSitemapEntries))
+			doc.URLs = doc.URLs[:min(len(doc.URLs), MaxSitemapEntries)]
+			doc.Sitemaps = doc.Sitemaps[:min(len(doc.Sitemaps), MaxSitemapEntries-len(doc.URLs))]
+		}
+
+		switch doc.XMLName.Local {
+		case "sitemapindex":
+			if depth >= maxSitemapIndexDepth {
+				report.addIssue(SitemapIssueParse, sitemapURL, "", "sitemap indexes are nested too deeply")
+				return
+			}
+			for _, child := range doc.Sitemaps {
+				report.checkLastMod(sitemapURL, child)
+				if loc := strings.TrimSpace(child.Loc); loc != "" {
+					read(loc, depth+1)
+				}
+			}
+		case "urlset":
+			for _, entry := range doc.URLs {
+				loc := strings.TrimSpace(entry.Loc)
+				if _, err := url.ParseRequestURI(loc); err != nil {
+					report.addIssue(SitemapIssueInvalidLink, sitemapURL, loc, "listed URL is not a valid absolute URL")
+					continue
+				}
+				report.checkLastMod(sitemapURL, entry)
+				if seenURLs[loc] {
+					continue
+				}
+				if len(report.URLs) >= MaxSitemapURLs {
+					report.addIssue(SitemapIssueURLLimit, sitemapURL, "",
+						fmt.Sprintf("stopped after %d URLs; later entries were not imported", MaxSitemapURLs))
+					return
+				}
+				seenURLs[loc] = true
+				report.URLs = append(report.URLs, SitemapURL{Loc: loc, LastMod: strings.TrimSpace(entry.LastMod), Sitemap: sitemapURL})
+			}
+		default:
+			report.addIssue(SitemapIssueParse, sitemapURL, "",
+				fmt.Sprintf("unexpected root element <%s>", doc.XMLName.Local))
+		}
+	}
+
+	for _, sitemapURL := range sitemaps {
+		read(sitemapURL, 0)
+	}
+
+	if opts.CheckStatus {
+		c.checkSitemapURLs(ctx, report, opts.Options)
+	}
+	return report
+}
+
+// fetchSitemap downloads and decodes a sitemap, transparently handling gzip.
+func (c *Crawler) fetchSitemap(ctx context.Context, sitemapURL string, opts Options) (*sitemapDocument, error) {
+	if !opts.IgnoreRobots {
+		if err := c.robots.wait(ctx, sitemapURL); err != nil {
+			return nil, fmt.Errorf("failed to fetch sitemap: %w", err)
+		}
+	}
+
+	resp, err := c.do(ctx, http.MethodGet, sitemapURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sitemap: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received status code %d", resp.StatusCode)
+	}
+
+	// Sniff the gzip magic bytes rather than trusting the extension or headers
+	body := bufio.NewReader(io.LimitReader(resp.Body, maxSitemapSize))
+	var reader io.Reader = body
+	if magic, err := body.Peek(2); err == nil && bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress sitemap: %w", err)
+		}
+		defer gz.Close()
+		reader = io.LimitReader(gz, maxSitemapSize)
+	}
+
+	var doc sitemapDocument
+	if err := xml.NewDecoder(reader).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse sitemap XML: %w", err)
+	}
+	return &doc, nil
+}
+
+// checkLastMod reports a lastmod value that lies in the future.
+func (r *SitemapReport) checkLastMod(sitemapURL string, entry sitemapLoc) {
+	lastMod := strings.TrimSpace(entry.LastMod)
+	if lastMod == "" {
+		return
+	}
+	t, ok := parseSitemapDate(lastMod)
+	if !ok {
+		r.addIssue(SitemapIssueParse, sitemapURL, entry.Loc, fmt.Sprintf("invalid lastmod %q", lastMod))
+		return
+	}
+	if t.After(time.Now().Add(24 * time.Hour)) {
+		r.addIssue(SitemapIssueFutureMod, sitemapURL, entry.Loc, fmt.Sprintf("lastmod %s is in the future", lastMod))
+	}
+}
+
+// checkSitemapURLs checks every listed URL like a link, with HEAD falling back
+// to a ranged GET and a per-host concurrency limit, and reports anything other
+// than 200 OK, including redirects. URLs not checked within the link check
+// timeout are left out of report.Checked.
+func (c *Crawler) checkSitemapURLs(ctx context.Context, report *SitemapReport, opts Options) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.LinkCheckTimeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	hosts := newHostLimiter(c.cfg.LinkCheckPerHost)
+	runWorkers(ctx, sitemapCheckWorkers, len(report.URLs), func(j int) {
+		entry := report.URLs[j]
+		issueType, message, checked := c.checkSitemapURL(ctx, hosts, entry.Loc, opts)
+		if !checked {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		report.Checked++
+		if issueType != "" {
+			report.addIssue(issueType, entry.Sitemap, entry.Loc, message)
+		}
+	})
+}
+
+// checkSitemapURL returns the issue type and message for a listed URL, or an
+// empty type when it responds with 200 OK. checked is false when the URL
+// could not be checked before the context was done.
+func (c *Crawler) checkSitemapURL(ctx context.Context, hosts *hostLimiter, loc string, opts Options) (issueType, message string, checked bool) {
+	result := c.checkLink(ctx, hosts, loc, opts)
+	switch {
+	case result.robotsSkipped:
+		return SitemapIssueDisallowed, "listed URL is disallowed by robots.txt", true
+	case !result.Checked:
+		return "", "", false
+	case len(result.RedirectChain) > 0:
+		// Sitemaps should list final URLs, so any redirect is an issue
+		hop := result.RedirectChain[0]
+		return SitemapIssueStatus, fmt.Sprintf("received status code %d redirecting to %s", hop.StatusCode, hop.Location), true
+	case result.Error != "":
+		return SitemapIssueStatus, result.Error, true
+	}
+
+	switch result.StatusCode {
+	case http.StatusOK, http.StatusPartialContent, http.StatusRequestedRangeNotSatisfiable:
+		// Partial responses answer the ranged GET sent when HEAD is rejected
+		return "", "", true
+	}
+	return SitemapIssueStatus, fmt.Sprintf("received status code %d", result.StatusCode), true
+}
+
+// addIssue appends an issue to the report.
+func (r *SitemapReport) addIssue(issueType, sitemapURL, pageURL, message string) {
+	r.Issues = append(r.Issues, SitemapIssue{Type: issueType, Sitemap: sitemapURL, URL: pageURL, Message: message})
+}
+
+// parseSitemapDate parses the W3C datetime formats allowed in lastmod.
+func parseSitemapDate(value string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04Z07:00", "2006-01-02", "2006-01", "2006"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
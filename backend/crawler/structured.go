@@ -0,0 +1,346 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Structured data formats.
+const (
+	FormatJSONLD    = "json-ld"
+	FormatMicrodata = "microdata"
+	FormatRDFa      = "rdfa"
+)
+
+// Structured data finding codes.
+const (
+	StructuredMalformedJSON   = "malformed_json_ld"
+	StructuredMissingType     = "missing_type"
+	StructuredMissingProperty = "missing_required_property"
+)
+
+// requiredSchemaProperties lists the properties checked for common schema.org
+// types. A property alternative such as "name|headline" is satisfied by either.
+var requiredSchemaProperties = map[string][]string{
+	"Product":        {"name", "offers|review|aggregateRating"},
+	"Offer":          {"price|priceSpecification", "priceCurrency|priceSpecification"},
+	"Article":        {"headline", "author", "datePublished", "image"},
+	"NewsArticle":    {"headline", "author", "datePublished", "image"},
+	"BlogPosting":    {"headline", "author", "datePublished", "image"},
+	"Organization":   {"name", "url"},
+	"BreadcrumbList": {"itemListElement"},
+	"ListItem":       {"position"},
+}
+
+// StructuredEntity is an item of structured data found on the page.
+type StructuredEntity struct {
+	Format     string         `json:"format"`
+	Types      []string       `json:"types"`
+	Properties map[string]any `json:"properties"`
+}
+
+// StructuredData holds the structured data entities of a page and validation findings.
+type StructuredData struct {
+	Entities []StructuredEntity `json:"entities"`
+	Findings []Finding          `json:"findings"`
+}
+
+// extractStructuredData extracts JSON-LD, microdata and RDFa entities from the
+// document and validates common schema.org types.
+func extractStructuredData(doc *html.Node) *StructuredData {
+	sd := &StructuredData{}
+
+	var traverse func(*html.Node)
+	traverse = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch {
+			case n.Data == "script" && strings.EqualFold(strings.TrimSpace(getAttr(n, "type")), "application/ld+json"):
+				sd.addJSONLD(n)
+				return
+			case hasAttr(n, "itemscope") && !hasAttr(n, "itemprop"):
+				// Top-level microdata item; nested items are read as its properties
+				sd.Entities = append(sd.Entities, microdataItem(n))
+			case hasAttr(n, "typeof") && !hasAttr(n, "property"):
+				// Top-level RDFa resource; nested resources are read as its properties
+				sd.Entities = append(sd.Entities, rdfaItem(n))
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			traverse(c)
+		}
+	}
+	traverse(doc)
+
+	for _, entity := range sd.Entities {
+		sd.validate(entity.Format, entity.Types, entity.Properties, "")
+	}
+	return sd
+}
+
+// addJSONLD parses a JSON-LD script block, flattening @graph and top-level arrays.
+func (sd *StructuredData) addJSONLD(n *html.Node) {
+	var value any
+	if err := json.Unmarshal([]byte(textContent(n)), &value); err != nil {
+		sd.Findings = append(sd.Findings, Finding{
+			Code:     StructuredMalformedJSON,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("JSON-LD block is not valid JSON: %v", err),
+			Path:     elementPath(n),
+		})
+		return
+	}
+
+	var items []any
+	switch v := value.(type) {
+	case []any:
+		items = v
+	case map[string]any:
+		if graph, ok := v["@graph"].([]any); ok {
+			items = graph
+		} else {
+			items = []any{v}
+		}
+	}
+	for _, item := range items {
+		if obj, ok := item.(map[string]any); ok {
+			sd.Entities = append(sd.Entities, StructuredEntity{
+				Format:     FormatJSONLD,
+				Types:      schemaTypes(obj["@type"]),
+				Properties: obj,
+			})
+		}
+	}
+}
+
+// validate checks an entity and its nested entities for required properties.
+func (sd *StructuredData) validate(format string, types []string, props map[string]any, path string) {
+	if len(types) == 0 && path == "" {
+		sd.Findings = append(sd.Findings, Finding{
+			Code:     StructuredMissingType,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("%s entity has no type", format),
+		})
+	}
+	for _, t := range types {
+		for _, required := range requiredSchemaProperties[t] {
+			if hasAnyProperty(props, strings.Split(required, "|")) {
+				continue
+			}
+			name := t
+			if path != "" {
+				name = path + " (" + t + ")"
+			}
+			sd.Findings = append(sd.Findings, Finding{
+				Code:     StructuredMissingProperty,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("%s %s is missing %s", format, name, strings.ReplaceAll(required, "|", " or ")),
+			})
+		}
+	}
+
+	// Validate nested typed objects, such as the offers of a product, in key
+	// order so findings are reported deterministically
+	keys := make([]string, 0, len(props))
+	for key := range props {
+		if !strings.HasPrefix(key, "@") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, nested := range nestedObjects(props[key]) {
+			nestedPath := key
+			if path != "" {
+				nestedPath = path + "." + key
+			}
+			sd.validate(format, schemaTypes(nested["@type"]), nested, nestedPath)
+		}
+	}
+}
+
+// hasAnyProperty reports whether props has a non-empty value for any of the keys.
+func hasAnyProperty(props map[string]any, keys []string) bool {
+	for _, key := range keys {
+		switch v := props[key].(type) {
+		case nil:
+		case string:
+			if strings.TrimSpace(v) != "" {
+				return true
+			}
+		case []any:
+			if len(v) > 0 {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// nestedObjects returns the objects held by a property value.
+func nestedObjects(value any) []map[string]any {
+	switch v := value.(type) {
+	case map[string]any:
+		return []map[string]any{v}
+	case []any:
+		var objects []map[string]any
+		for _, item := range v {
+			if obj, ok := item.(map[string]any); ok {
+				objects = append(objects, obj)
+			}
+		}
+		return objects
+	}
+	return nil
+}
+
+// schemaTypes normalizes a type value to short schema.org type names.
+func schemaTypes(value any) []string {
+	var raw []string
+	switch v := value.(type) {
+	case string:
+		raw = strings.Fields(v)
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				raw = append(raw, s)
+			}
+		}
+	}
+	types := make([]string, 0, len(raw))
+	for _, t := range raw {
+		t = strings.TrimPrefix(t, "schema:")
+		if i := strings.LastIndexAny(t, "/#"); i >= 0 {
+			t = t[i+1:]
+		}
+		if t != "" {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// microdataItem reads an itemscope element and its itemprop descendants.
+func microdataItem(n *html.Node) StructuredEntity {
+	entity := StructuredEntity{
+		Format:     FormatMicrodata,
+		Types:      schemaTypes(getAttr(n, "itemtype")),
+		Properties: make(map[string]any),
+	}
+	if len(entity.Types) > 0 {
+		entity.Properties["@type"] = entity.Types[0]
+	}
+	collectProperties(n, "itemprop", "itemscope", entity.Properties, microdataValue)
+	return entity
+}
+
+// rdfaItem reads a typeof element and its property descendants.
+func rdfaItem(n *html.Node) StructuredEntity {
+	entity := StructuredEntity{
+		Format:     FormatRDFa,
+		Types:      schemaTypes(getAttr(n, "typeof")),
+		Properties: make(map[string]any),
+	}
+	if len(entity.Types) > 0 {
+		entity.Properties["@type"] = entity.Types[0]
+	}
+	collectProperties(n, "property", "typeof", entity.Properties, rdfaValue)
+	return entity
+}
+
+// collectProperties walks the descendants of an item, adding every element
+// carrying propAttr to props. Elements that start a nested item (scopeAttr)
+// become nested objects and their subtree is not searched further.
+func collectProperties(item *html.Node, propAttr, scopeAttr string, props map[string]any, value func(*html.Node) string) {
+	for c := item.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+		names := strings.Fields(getAttr(c, propAttr))
+		nested := hasAttr(c, scopeAttr)
+
+		var v any
+		if nested {
+			child := make(map[string]any)
+			if types := schemaTypes(getAttr(c, itemTypeAttr(scopeAttr))); len(types) > 0 {
+				child["@type"] = types[0]
+			}
+			collectProperties(c, propAttr, scopeAttr, child, value)
+			v = child
+		} else if len(names) > 0 {
+			v = value(c)
+		}
+
+		for _, name := range names {
+			name = strings.TrimPrefix(name, "schema:")
+			addProperty(props, name, v)
+		}
+		if !nested {
+			collectProperties(c, propAttr, scopeAttr, props, value)
+		}
+	}
+}
+
+// itemTypeAttr returns the attribute holding the type of a nested item.
+func itemTypeAttr(scopeAttr string) string {
+	if scopeAttr == "itemscope" {
+		return "itemtype"
+	}
+	return "typeof"
+}
+
+// addProperty sets a property, turning repeated properties into lists.
+func addProperty(props map[string]any, name string, value any) {
+	existing, ok := props[name]
+	if !ok {
+		props[name] = value
+		return
+	}
+	if list, ok := existing.([]any); ok {
+		props[name] = append(list, value)
+		return
+	}
+	props[name] = []any{existing, value}
+}
+
+// microdataValue returns the value of an itemprop element per the microdata spec.
+func microdataValue(n *html.Node) string {
+	switch n.Data {
+	case "meta":
+		return getAttr(n, "content")
+	case "a", "link", "area":
+		return getAttr(n, "href")
+	case "img", "audio", "video", "source", "iframe", "embed":
+		return getAttr(n, "src")
+	case "object":
+		return getAttr(n, "data")
+	case "time":
+		if v, ok := attrValue(n, "datetime"); ok {
+			return v
+		}
+	case "data", "meter":
+		return getAttr(n, "value")
+	}
+	return strings.Join(strings.Fields(textContent(n)), " ")
+}
+
+// rdfaValue returns the value of an RDFa property element.
+func rdfaValue(n *html.Node) string {
+	for _, key := range []string{"content", "href", "src", "resource"} {
+		if v, ok := attrValue(n, key); ok {
+			return v
+		}
+	}
+	return strings.Join(strings.Fields(textContent(n)), " ")
+}
+
+// hasAttr reports whether the element has the named attribute.
+func hasAttr(n *html.Node, key string) bool {
+	_, ok := attrValue(n, key)
+	return ok
+}
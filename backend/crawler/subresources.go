@@ -0,0 +1,269 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/publicsuffix"
+)
+
+// Subresource types.
+const (
+	SubresourceScript     = "script"
+	SubresourceStylesheet = "stylesheet"
+	SubresourceImage      = "image"
+	SubresourceFont       = "font"
+	SubresourceIframe     = "iframe"
+	SubresourceMedia      = "media"
+)
+
+// fontExtensions are file extensions of web fonts referenced from CSS.
+var fontExtensions = map[string]bool{
+	".woff2": true,
+	".woff":  true,
+	".ttf":   true,
+	".otf":   true,
+	".eot":   true,
+}
+
+// Subresource is a resource the page loads, such as a script or an image.
+type Subresource struct {
+	URL            string `json:"url"`
+	Type           string `json:"type"`
+	Element        string `json:"element"`
+	ThirdParty     bool   `json:"third_party"`     // served from a different site than the page
+	RenderBlocking bool   `json:"render_blocking"` // script in <head> without async or defer
+	Checked        bool   `json:"checked"`         // size requested with HEAD
+	Size           int64  `json:"size"`            // Content-Length, -1 when unknown
+	ContentType    string `json:"content_type,omitempty"`
+}
+
+// ResourceInventory lists the subresources of a page and summarizes its weight.
+type ResourceInventory struct {
+	Resources      []Subresource  `json:"resources"`
+	Counts         map[string]int `json:"counts"`          // resources per type
+	ThirdParty     int            `json:"third_party"`     // number of third-party resources
+	RenderBlocking int            `json:"render_blocking"` // number of render-blocking scripts
+	TotalSize      int64          `json:"total_size"`      // page plus subresources of known size, in bytes
+	UnknownSizes   int            `json:"unknown_sizes"`   // resources whose size is unknown
+}
+
+// inventoryResources lists the scripts, stylesheets, images, fonts, iframes
+// and media the document references, resolved against baseURL. Each distinct
+// URL is listed once.
+func inventoryResources(doc *html.Node, pageURL, baseURL string) *ResourceInventory {
+	inv := &ResourceInventory{Counts: make(map[string]int)}
+	pageSite := siteOf(pageURL)
+	seen := make(map[string]bool)
+
+	add := func(n *html.Node, ref, kind string, blocking bool) {
+		ref = strings.TrimSpace(ref)
+		if ref == "" || strings.HasPrefix(ref, "data:") {
+			return
+		}
+		resolved, ok := resolveCrawlableURL(baseURL, ref)
+		if !ok || seen[resolved] {
+			return
+		}
+		seen[resolved] = true
+		inv.Resources = append(inv.Resources, Subresource{
+			URL:            resolved,
+			Type:           kind,
+			Element:        n.Data,
+			ThirdParty:     siteOf(resolved) != pageSite,
+			RenderBlocking: blocking,
+			Size:           -1,
+		})
+	}
+	addSrcset := func(n *html.Node, kind string) {
+		for _, ref := range srcsetURLs(n) {
+			add(n, ref, kind, false)
+		}
+	}
+	addCSS := func(n *html.Node, css string) {
+		for _, ref := range cssRefs(css) {
+			kind := SubresourceImage
+			switch {
+			case ref.isImport:
+				kind = SubresourceStylesheet
+			case fontExtensions[strings.ToLower(path.Ext(strings.SplitN(ref.url, "?", 2)[0]))]:
+				kind = SubresourceFont
+			}
+			add(n, ref.url, kind, false)
+		}
+	}
+
+	var traverse func(*html.Node, bool)
+	traverse = func(n *html.Node, inHead bool) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "head":
+				inHead = true
+			case "script":
+				if src := getAttr(n, "src"); src != "" {
+					add(n, src, SubresourceScript, inHead && isBlockingScript(n))
+				}
+			case "link":
+				rels := strings.Fields(strings.ToLower(getAttr(n, "rel")))
+				for _, rel := range rels {
+					switch rel {
+					case "stylesheet":
+						add(n, getAttr(n, "href"), SubresourceStylesheet, false)
+					case "icon", "apple-touch-icon":
+						add(n, getAttr(n, "href"), SubresourceImage, false)
+					case "preload", "modulepreload":
+						if kind := preloadType(n, rel); kind != "" {
+							add(n, getAttr(n, "href"), kind, false)
+						}
+					}
+				}
+			case "img":
+				add(n, getAttr(n, "src"), SubresourceImage, false)
+				addSrcset(n, SubresourceImage)
+			case "iframe", "frame":
+				add(n, getAttr(n, "src"), SubresourceIframe, false)
+			case "video":
+				add(n, getAttr(n, "src"), SubresourceMedia, false)
+				add(n, getAttr(n, "poster"), SubresourceImage, false)
+			case "audio", "track":
+				add(n, getAttr(n, "src"), SubresourceMedia, false)
+			case "source":
+				kind := SubresourceMedia
+				if n.Parent != nil && n.Parent.Data == "picture" {
+					kind = SubresourceImage
+				}
+				add(n, getAttr(n, "src"), kind, false)
+				addSrcset(n, kind)
+			case "style":
+				addCSS(n, textContent(n))
+			}
+			if style, ok := attrValue(n, "style"); ok {
+				addCSS(n, style)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			traverse(c, inHead)
+		}
+	}
+	traverse(doc, false)
+
+	for _, r := range inv.Resources {
+		inv.Counts[r.Type]++
+		if r.ThirdParty {
+			inv.ThirdParty++
+		}
+		if r.RenderBlocking {
+			inv.RenderBlocking++
+		}
+	}
+	return inv
+}
+
+// isBlockingScript reports whether an external script blocks rendering: it is
+// a classic script without async or defer. Module scripts are deferred.
+func isBlockingScript(n *html.Node) bool {
+	if hasAttr(n, "async") || hasAttr(n, "defer") {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(getAttr(n, "type"))) {
+	case "", "text/javascript", "application/javascript":
+		return true
+	}
+	return false
+}
+
+// preloadType maps the "as" attribute of a preload link to a subresource type.
+func preloadType(n *html.Node, rel string) string {
+	if rel == "modulepreload" {
+		return SubresourceScript
+	}
+	switch strings.ToLower(getAttr(n, "as")) {
+	case "script":
+		return SubresourceScript
+	case "style":
+		return SubresourceStylesheet
+	case "image":
+		return SubresourceImage
+	case "font":
+		return SubresourceFont
+	case "video", "audio", "track":
+		return SubresourceMedia
+	}
+	return ""
+}
+
+// siteOf returns the registrable domain of a URL, such as example.co.uk for
+// https://cdn.example.co.uk/app.js, falling back to the host.
+func siteOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	host := strings.ToLower(u.Hostname())
+	if site, err := publicsuffix.EffectiveTLDPlusOne(host); err == nil {
+		return site
+	}
+	return host
+}
+
+// sizeResources requests every subresource with HEAD to record its size and
+// content type, using the link checker's worker pool limits and deadline.
+// Resources disallowed by robots.txt or not reached in time keep an unknown size.
+func (c *Crawler) sizeResources(ctx context.Context, inv *ResourceInventory, opts Options) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.LinkCheckTimeout)
+	defer cancel()
+
+	hosts := newHostLimiter(c.cfg.LinkCheckPerHost)
+	runWorkers(ctx, c.cfg.LinkCheckWorkers, len(inv.Resources), func(j int) {
+		c.sizeResource(ctx, hosts, &inv.Resources[j], opts)
+	})
+}
+
+// sizeResource requests a single subresource with HEAD.
+func (c *Crawler) sizeResource(ctx context.Context, hosts *hostLimiter, r *Subresource, opts Options) {
+	if !opts.IgnoreRobots {
+		if !c.robots.allowed(ctx, r.URL) {
+			return
+		}
+		if err := c.robots.wait(ctx, r.URL); err != nil {
+			return
+		}
+	}
+
+	host := ""
+	if u, err := url.Parse(r.URL); err == nil {
+		host = u.Host
+	}
+	if err := hosts.acquire(ctx, host); err != nil {
+		return
+	}
+	defer hosts.release(host)
+
+	resp, _, err := c.fetch(ctx, http.MethodHead, r.URL, nil)
+	if err != nil {
+		return
+	}
+	resp.Body.Close()
+	r.Checked = true
+	if resp.StatusCode == http.StatusOK {
+		r.Size = resp.ContentLength
+		r.ContentType = resp.Header.Get("Content-Type")
+	}
+}
+
+// summarizeWeight totals the page size and the known subresource sizes.
+func (inv *ResourceInventory) summarizeWeight(pageSize int64) {
+	inv.TotalSize = pageSize
+	inv.UnknownSizes = 0
+	for _, r := range inv.Resources {
+		if r.Size >= 0 {
+			inv.TotalSize += r.Size
+		} else {
+			inv.UnknownSizes++
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package crawler
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// hiddenElements hold content that is not rendered as page text.
+var hiddenElements = map[string]bool{
+	"head":     true,
+	"script":   true,
+	"style":    true,
+	"noscript": true,
+	"template": true,
+	"svg":      true,
+	"canvas":   true,
+}
+
+// blockElements start a new line of page text.
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "br": true,
+	"dd": true, "div": true, "dl": true, "dt": true, "figcaption": true,
+	"figure": true, "footer": true, "form": true, "h1": true, "h2": true,
+	"h3": true, "h4": true, "h5": true, "h6": true, "header": true,
+	"hr": true, "li": true, "main": true, "nav": true, "ol": true,
+	"p": true, "pre": true, "section": true, "table": true, "td": true,
+	"th": true, "tr": true, "ul": true,
+}
+
+// boilerplateElements hold navigation and page chrome rather than main content.
+var boilerplateElements = map[string]bool{
+	"nav":    true,
+	"header": true,
+	"footer": true,
+	"aside":  true,
+	"form":   true,
+}
+
+// boilerplateRoles are ARIA landmark roles of page chrome.
+var boilerplateRoles = map[string]bool{
+	"navigation":    true,
+	"banner":        true,
+	"contentinfo":   true,
+	"complementary": true,
+	"search":        true,
+}
+
+// visibleText returns the rendered text of the document body, one line per
+// block element with whitespace collapsed and empty lines dropped.
+func visibleText(doc *html.Node) string {
+	return collectText(doc, false)
+}
+
+// mainText returns the text of the page's main content: the <main> element
+// or role="main" landmark when present, otherwise the body, leaving out
+// navigation, headers, footers, sidebars and forms.
+func mainText(doc *html.Node) string {
+	root := findMain(doc)
+	if root == nil {
+		root = doc
+	}
+	return collectText(root, true)
+}
+
+// findMain returns the first <main> element or role="main" landmark.
+func findMain(n *html.Node) *html.Node {
+	if n.Type == html.ElementNode && (n.Data == "main" || strings.EqualFold(getAttr(n, "role"), "main")) {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findMain(c); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// collectText returns the rendered text below root, one line per block
+// element, optionally skipping boilerplate elements.
+func collectText(root *html.Node, skipBoilerplate bool) string {
+	var lines []string
+	var line strings.Builder
+	flush := func() {
+		if text := strings.Join(strings.Fields(line.String()), " "); text != "" {
+			lines = append(lines, text)
+		}
+		line.Reset()
+	}
+
+	var traverse func(*html.Node)
+	traverse = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			line.WriteString(n.Data)
+			return
+		case html.ElementNode:
+			if hiddenElements[n.Data] || hasAttr(n, "hidden") {
+				return
+			}
+			if skipBoilerplate && n != root &&
+				(boilerplateElements[n.Data] || boilerplateRoles[strings.ToLower(getAttr(n, "role"))]) {
+				return
+			}
+		}
+		block := n.Type == html.ElementNode && blockElements[n.Data]
+		if block {
+			flush()
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			traverse(c)
+		}
+		if block {
+			flush()
+		}
+	}
+	traverse(root)
+	flush()
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,215 @@
+package crawler
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TLS finding codes.
+const (
+	TLSCertExpired      = "certificate_expired"
+	TLSCertExpiringSoon = "certificate_expiring_soon"
+	TLSCertUntrusted    = "certificate_untrusted"
+	TLSHostnameMismatch = "hostname_mismatch"
+	TLSLegacyVersion    = "legacy_tls_version"
+)
+
+// CertificateInfo describes a certificate presented by the server.
+type CertificateInfo struct {
+	Subject   string    `json:"subject"`
+	Issuer    string    `json:"issuer"`
+	DNSNames  []string  `json:"dns_names"` // subject alternative names
+	NotBefore time.Time `json:"not_before"`
+	NotAfter  time.Time `json:"not_after"`
+}
+
+// TLSInfo describes the TLS connection of an HTTPS page.
+type TLSInfo struct {
+	Version         string            `json:"version"`
+	CipherSuite     string            `json:"cipher_suite"`
+	ServerName      string            `json:"server_name"`
+	HostnameMatch   bool              `json:"hostname_match"`
+	DaysUntilExpiry int               `json:"days_until_expiry"` // for the leaf certificate
+	Certificates    []CertificateInfo `json:"certificates"`      // leaf first
+	Findings        []Finding         `json:"findings"`
+}
+
+// inspectTLS records the connection state of a response served over TLS,
+// warning when the leaf certificate expires within warnDays. It returns nil
+// for plain HTTP responses.
+func inspectTLS(state *tls.ConnectionState, host string, warnDays int, now time.Time) *TLSInfo {
+	if state == nil {
+		return nil
+	}
+	info := &TLSInfo{
+		Version:     tls.VersionName(state.Version),
+		CipherSuite: tls.CipherSuiteName(state.CipherSuite),
+		ServerName:  state.ServerName,
+	}
+	if info.ServerName == "" {
+		// No SNI is sent for IP address hosts
+		info.ServerName = host
+	}
+	if state.Version < tls.VersionTLS12 {
+		info.Findings = append(info.Findings, Finding{
+			Code:     TLSLegacyVersion,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("connection negotiated %s; TLS 1.2 or later is recommended", info.Version),
+		})
+	}
+
+	for _, cert := range state.PeerCertificates {
+		info.Certificates = append(info.Certificates, CertificateInfo{
+			Subject:   cert.Subject.String(),
+			Issuer:    cert.Issuer.String(),
+			DNSNames:  cert.DNSNames,
+			NotBefore: cert.NotBefore,
+			NotAfter:  cert.NotAfter,
+		})
+	}
+	if len(state.PeerCertificates) == 0 {
+		return info
+	}
+
+	leaf := state.PeerCertificates[0]
+	info.HostnameMatch = leaf.VerifyHostname(strings.TrimSuffix(host, ".")) == nil
+	if !info.HostnameMatch {
+		info.Findings = append(info.Findings, Finding{
+			Code:     TLSHostnameMismatch,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("certificate is not valid for %s", host),
+		})
+	}
+
+	info.DaysUntilExpiry = int(leaf.NotAfter.Sub(now).Hours() / 24)
+	switch {
+	case now.After(leaf.NotAfter):
+		info.Findings = append(info.Findings, Finding{
+			Code:     TLSCertExpired,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("certificate expired on %s", leaf.NotAfter.Format(time.DateOnly)),
+		})
+	case info.DaysUntilExpiry < warnDays:
+		info.Findings = append(info.Findings, Finding{
+			Code:     TLSCertExpiringSoon,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("certificate expires in %d days, on %s", info.DaysUntilExpiry, leaf.NotAfter.Format(time.DateOnly)),
+		})
+	}
+	return info
+}
+
+// isCertificateError reports whether err stems from a server certificate that
+// failed verification.
+func isCertificateError(err error) bool {
+	var certErr *tls.CertificateVerificationError
+	return errors.As(err, &certErr)
+}
+
+// crawlUntrustedPage returns the crawl data of a page whose certificate failed
+// verification. The page body is not fetched; its certificate chain is
+// recorded by a handshake without verification, whose HEAD response gives the
+// resource type, and the failure is reported as a TLS finding.
+func (c *Crawler) crawlUntrustedPage(ctx context.Context, targetURL string, chain []RedirectHop, verifyErr error, startTime time.Time) (*CrawlData, error) {
+	failedURL := targetURL
+	var urlErr *url.Error
+	if errors.As(verifyErr, &urlErr) {
+		failedURL = urlErr.URL
+	}
+	u, err := url.Parse(failedURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch URL %s: %w", targetURL, verifyErr)
+	}
+
+	state, mediaType, err := c.probeInsecurely(ctx, u)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch URL %s: %w (inspecting certificate: %v)", targetURL, verifyErr, err)
+	}
+	info := inspectTLS(state, u.Hostname(), c.cfg.CertExpiryWarnDays, time.Now())
+
+	// Expired and mismatched certificates are already reported; anything else
+	// failed to chain to a trusted root
+	if !hasErrorFinding(info.Findings) {
+		var certErr *tls.CertificateVerificationError
+		errors.As(verifyErr, &certErr)
+		info.Findings = append(info.Findings, Finding{
+			Code:     TLSCertUntrusted,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("certificate failed verification: %v", certErr.Err),
+		})
+	}
+
+	return &CrawlData{
+		URL:                  targetURL,
+		FinalURL:             failedURL,
+		RedirectChain:        chain,
+		RedirectChainTooLong: len(chain) > c.cfg.RedirectWarnHops,
+		MediaType:            mediaType,
+		ResourceType:         resourceType(mediaType),
+		TLS:                  info,
+		ProcessingTime:       time.Since(startTime).Seconds(),
+	}, nil
+}
+
+// probeInsecurely completes a TLS handshake with the host of u without
+// verifying its certificate and sends a HEAD request for u over the
+// connection. It returns the connection state and the media type of the
+// response, which is empty when the HEAD request fails.
+func (c *Crawler) probeInsecurely(ctx context.Context, u *url.URL) (*tls.ConnectionState, string, error) {
+	port := u.Port()
+	if port == "" {
+		port = "443"
+	}
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: c.cfg.RequestTimeout},
+		Config: &tls.Config{
+			ServerName:         u.Hostname(),
+			InsecureSkipVerify: true, // the chain is only inspected and no body is read
+		},
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(u.Hostname(), port))
+	if err != nil {
+		return nil, "", err
+	}
+	defer conn.Close()
+	state := conn.(*tls.Conn).ConnectionState()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, u.String(), nil)
+	if err != nil {
+		return &state, "", nil
+	}
+	req.Header.Set("User-Agent", UserAgent)
+	conn.SetDeadline(time.Now().Add(c.cfg.RequestTimeout))
+	if err := req.Write(conn); err != nil {
+		return &state, "", nil
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		return &state, "", nil
+	}
+	resp.Body.Close()
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		// Without a body the media type cannot be sniffed
+		return &state, "", nil
+	}
+	return &state, detectMediaType(contentType, nil), nil
+}
+
+// hasErrorFinding reports whether any of the findings is an error.
+func hasErrorFinding(findings []Finding) bool {
+	for _, f := range findings {
+		if f.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+package crawler
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTLSTestServer starts an HTTPS server serving a small HTML page. Its
+// certificate is valid for example.com and 127.0.0.1, not for localhost.
+func newTLSTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, "<html><head><title>TLS</title></head><body></body></html>")
+	}))
+	// Handshakes rejected by the crawler are expected
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// trustingCrawler returns a crawler that trusts the test server's certificate.
+func trustingCrawler(srv *httptest.Server) *Crawler {
+	client := srv.Client()
+	client.CheckRedirect = noRedirects
+	cfg := DefaultConfig()
+	cfg.Fetcher = client
+	return NewCrawler(cfg)
+}
+
+// connectionState returns the TLS connection state of a request to the server.
+func connectionState(t *testing.T, srv *httptest.Server) *tls.ConnectionState {
+	t.Helper()
+	resp, err := srv.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatalf("request to test server: %v", err)
+	}
+	resp.Body.Close()
+	return resp.TLS
+}
+
+// findingCodes returns the codes of findings.
+func findingCodes(findings []Finding) []string {
+	codes := make([]string, 0, len(findings))
+	for _, f := range findings {
+		codes = append(codes, f.Code)
+	}
+	return codes
+}
+
+func TestCrawlRecordsTLSVersionAndChain(t *testing.T) {
+	srv := newTLSTestServer(t)
+
+	data, err := trustingCrawler(srv).Crawl(context.Background(), srv.URL, Options{IgnoreRobots: true})
+	if err != nil {
+		t.Fatalf("Crawl: %v", err)
+	}
+	info := data.TLS
+	if info == nil {
+		t.Fatal("TLS info missing for HTTPS page")
+	}
+	if info.Version != "TLS 1.3" {
+		t.Errorf("Version = %q, want TLS 1.3", info.Version)
+	}
+	if info.CipherSuite == "" {
+		t.Error("CipherSuite is empty")
+	}
+	if info.ServerName != "127.0.0.1" {
+		t.Errorf("ServerName = %q, want 127.0.0.1", info.ServerName)
+	}
+	if !info.HostnameMatch {
+		t.Error("HostnameMatch = false, want true")
+	}
+	leaf := srv.Certificate()
+	if len(info.Certificates) != 1 {
+		t.Fatalf("got %d certificates, want 1", len(info.Certificates))
+	}
+	if got := info.Certificates[0]; got.Subject != leaf.Subject.String() || !got.NotAfter.Equal(leaf.NotAfter) {
+		t.Errorf("certificate = %+v, want subject %q expiring %s", got, leaf.Subject, leaf.NotAfter)
+	}
+	if len(info.Findings) != 0 {
+		t.Errorf("findings = %v, want none", findingCodes(info.Findings))
+	}
+}
+
+func TestInspectTLSExpiryWindow(t *testing.T) {
+	srv := newTLSTestServer(t)
+	state := connectionState(t, srv)
+	notAfter := srv.Certificate().NotAfter
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		wantDays int
+		want     string
+	}{
+		{"valid", notAfter.Add(-31 * 24 * time.Hour), 31, ""},
+		{"expiring soon", notAfter.Add(-10 * 24 * time.Hour), 10, TLSCertExpiringSoon},
+		{"expired", notAfter.Add(24 * time.Hour), -1, TLSCertExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := inspectTLS(state, "127.0.0.1", 30, tt.now)
+			if info.DaysUntilExpiry != tt.wantDays {
+				t.Errorf("DaysUntilExpiry = %d, want %d", info.DaysUntilExpiry, tt.wantDays)
+			}
+			codes := findingCodes(info.Findings)
+			if tt.want == "" && len(codes) != 0 {
+				t.Errorf("findings = %v, want none", codes)
+			}
+			if tt.want != "" && (len(codes) != 1 || codes[0] != tt.want) {
+				t.Errorf("findings = %v, want [%s]", codes, tt.want)
+			}
+		})
+	}
+}
+
+func TestCrawlReportsHostnameMismatch(t *testing.T) {
+	srv := newTLSTestServer(t)
+	pageURL := strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)
+
+	data, err := trustingCrawler(srv).Crawl(context.Background(), pageURL, Options{IgnoreRobots: true})
+	if err != nil {
+		t.Fatalf("Crawl: %v", err)
+	}
+	if data.TLS == nil {
+		t.Fatal("TLS info missing for a certificate that failed verification")
+	}
+	if data.TLS.HostnameMatch {
+		t.Error("HostnameMatch = true, want false")
+	}
+	if len(data.TLS.Certificates) != 1 {
+		t.Errorf("got %d certificates, want the chain recorded by the handshake", len(data.TLS.Certificates))
+	}
+	if codes := findingCodes(data.TLS.Findings); len(codes) != 1 || codes[0] != TLSHostnameMismatch {
+		t.Errorf("findings = %v, want [%s]", codes, TLSHostnameMismatch)
+	}
+	if data.Title != "" {
+		t.Errorf("Title = %q, want the untrusted page left unfetched", data.Title)
+	}
+	if data.ResourceType != ResourceHTML {
+		t.Errorf("ResourceType = %q, want %q from the response's media type", data.ResourceType, ResourceHTML)
+	}
+}
+
+func TestCrawlReportsUntrustedCertificate(t *testing.T) {
+	srv := newTLSTestServer(t)
+
+	// The default crawler does not trust the test server's self-signed root
+	data, err := NewCrawler(nil).Crawl(context.Background(), srv.URL, Options{IgnoreRobots: true})
+	if err != nil {
+		t.Fatalf("Crawl: %v", err)
+	}
+	if data.TLS == nil {
+		t.Fatal("TLS info missing for a certificate that failed verification")
+	}
+	if !data.TLS.HostnameMatch {
+		t.Error("HostnameMatch = false, want true")
+	}
+	if codes := findingCodes(data.TLS.Findings); len(codes) != 1 || codes[0] != TLSCertUntrusted {
+		t.Errorf("findings = %v, want [%s]", codes, TLSCertUntrusted)
+	}
+}
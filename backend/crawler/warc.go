@@ -0,0 +1,188 @@
+package crawler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// warcVersion is the WARC format version written to archives.
+const warcVersion = "WARC/1.1"
+
+// ArchivePath returns the path of the WARC file holding the exchanges of a
+// crawl request.
+func (c *Crawler) ArchivePath(requestID uint) string {
+	return filepath.Join(c.cfg.ArchiveDir, fmt.Sprintf("crawl-%d.warc.gz", requestID))
+}
+
+// warcWriter appends records to a gzipped WARC file, compressing each record
+// as its own gzip member so that the file can be appended to by later crawls.
+type warcWriter struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// openWARC opens a WARC file for appending, creating it with a warcinfo
+// record when it does not exist yet.
+func openWARC(path string) (*warcWriter, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	w := &warcWriter{file: file}
+
+	info, err := file.Stat()
+	if err == nil && info.Size() == 0 {
+		fields := fmt.Sprintf("software: %s\r\nformat: WARC File Format 1.1\r\n", UserAgent)
+		_, err = w.writeRecord([][2]string{
+			{"WARC-Type", "warcinfo"},
+			{"WARC-Filename", filepath.Base(path)},
+			{"Content-Type", "application/warc-fields"},
+		}, []byte(fields))
+	}
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to initialize archive: %w", err)
+	}
+	return w, nil
+}
+
+// writeExchange writes a request record and the response record of its
+// response with the given body, returning the WARC-Record-ID of the response
+// record. Truncated bodies are marked with WARC-Truncated.
+func (w *warcWriter) writeExchange(req *http.Request, resp *http.Response, body []byte, truncated bool) (string, error) {
+	reqDump, err := httputil.DumpRequestOut(req, false)
+	if err != nil {
+		return "", fmt.Errorf("failed to dump request: %w", err)
+	}
+	respDump, err := dumpResponse(resp, body, truncated)
+	if err != nil {
+		return "", fmt.Errorf("failed to dump response: %w", err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	target := req.URL.String()
+	headers := [][2]string{
+		{"WARC-Type", "response"},
+		{"WARC-Target-URI", target},
+		{"Content-Type", "application/http;msgtype=response"},
+	}
+	if truncated {
+		headers = append(headers, [2]string{"WARC-Truncated", "length"})
+	}
+	responseID, err := w.writeRecord(headers, respDump)
+	if err != nil {
+		return "", err
+	}
+	if _, err := w.writeRecord([][2]string{
+		{"WARC-Type", "request"},
+		{"WARC-Target-URI", target},
+		{"WARC-Concurrent-To", responseID},
+		{"Content-Type", "application/http;msgtype=request"},
+	}, reqDump); err != nil {
+		return "", err
+	}
+	return responseID, nil
+}
+
+// writeRecord writes a single record as a gzip member and returns its
+// WARC-Record-ID. Callers other than openWARC must hold w.mu.
+func (w *warcWriter) writeRecord(headers [][2]string, block []byte) (string, error) {
+	id, err := newRecordID()
+	if err != nil {
+		return "", err
+	}
+	digest := sha1.Sum(block)
+
+	var buf bytes.Buffer
+	buf.WriteString(warcVersion + "\r\n")
+	fmt.Fprintf(&buf, "WARC-Record-ID: %s\r\n", id)
+	fmt.Fprintf(&buf, "WARC-Date: %s\r\n", time.Now().UTC().Format(time.RFC3339))
+	for _, h := range headers {
+		fmt.Fprintf(&buf, "%s: %s\r\n", h[0], h[1])
+	}
+	fmt.Fprintf(&buf, "WARC-Block-Digest: sha1:%s\r\n", base32.StdEncoding.EncodeToString(digest[:]))
+	buf.WriteString("Content-Length: " + strconv.Itoa(len(block)) + "\r\n\r\n")
+	buf.Write(block)
+	buf.WriteString("\r\n\r\n")
+
+	// Compressing into memory cannot fail, so only the file write is checked
+	var member bytes.Buffer
+	gz := gzip.NewWriter(&member)
+	gz.Write(buf.Bytes())
+	gz.Close()
+	if _, err := w.file.Write(member.Bytes()); err != nil {
+		return "", fmt.Errorf("failed to write archive record: %w", err)
+	}
+	return id, nil
+}
+
+// Close closes the WARC file.
+func (w *warcWriter) Close() error {
+	return w.file.Close()
+}
+
+// newRecordID returns a random WARC-Record-ID URN.
+func newRecordID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate record ID: %w", err)
+	}
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("<urn:uuid:%x-%x-%x-%x-%x>", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// archivingFetcher writes every exchange sent through it to a WARC file,
+// archiving at most limit bytes of each response body.
+type archivingFetcher struct {
+	next  Fetcher
+	warc  *warcWriter
+	limit int64
+
+	mu  sync.Mutex
+	ids map[string]string // response record IDs by request URL
+}
+
+// Do sends the request and archives the exchange.
+func (f *archivingFetcher) Do(req *http.Request) (*http.Response, error) {
+	resp, err := f.next.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	body, truncated, err := bufferBody(resp, f.limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body for archiving: %w", err)
+	}
+
+	id, err := f.warc.writeExchange(req, resp, body, truncated)
+	if err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to archive response: %w", err)
+	}
+	f.mu.Lock()
+	f.ids[req.URL.String()] = id
+	f.mu.Unlock()
+	return resp, nil
+}
+
+// recordID returns the response record ID archived for a URL.
+func (f *archivingFetcher) recordID(rawURL string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.ids[rawURL]
+}
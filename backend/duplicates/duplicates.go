@@ -0,0 +1,138 @@
+// Package duplicates clusters crawl results whose text is nearly the same.
+package duplicates
+
+import (
+	"math"
+	"math/bits"
+	"sort"
+	"strconv"
+	"time"
+
+	"url_analyzer/backend/models"
+)
+
+// Similarity thresholds accepted by Find.
+const (
+	DefaultThreshold = 0.8
+	MinThreshold     = 0.6 // unrelated texts differ in about half of the bits
+)
+
+// MaxResults is the number of most recent results compared for duplicates.
+const MaxResults = 10000
+
+// fingerprintBits is the size of a SimHash fingerprint.
+const fingerprintBits = 64
+
+// Page is a result in a cluster of near-duplicates.
+type Page struct {
+	ResultID   uint      `json:"result_id"`
+	PageURLID  uint      `json:"page_url_id"`
+	URL        string    `json:"url"`
+	Title      string    `json:"title"`
+	WordCount  int       `json:"word_count"`
+	CrawledAt  time.Time `json:"crawled_at"`
+	Similarity float64   `json:"similarity"` // to the first page of the cluster
+}
+
+// Cluster is a group of pages linked by near-duplicate text.
+type Cluster struct {
+	Size  int    `json:"size"`
+	Pages []Page `json:"pages"`
+}
+
+// Report lists the clusters of near-duplicate pages.
+type Report struct {
+	Threshold   float64   `json:"threshold"`
+	MaxDistance int       `json:"max_distance"` // differing fingerprint bits allowed between duplicates
+	Compared    int       `json:"compared"`     // pages compared
+	Clusters    []Cluster `json:"clusters"`
+}
+
+// Find clusters the results whose fingerprints are at least threshold
+// similar, where similarity is the share of equal fingerprint bits. Pages
+// join a cluster when they are similar to any of its pages. Only the first
+// result of each page URL group is compared, so results must be ordered
+// newest first for the latest crawl of every page to be used.
+func Find(results []models.CrawlResult, threshold float64) *Report {
+	report := &Report{
+		Threshold:   threshold,
+		MaxDistance: int(math.Floor((1 - threshold) * fingerprintBits)),
+		Clusters:    []Cluster{},
+	}
+
+	var pages []models.CrawlResult
+	var fingerprints []uint64
+	seen := make(map[uint]bool)
+	for _, result := range results {
+		if result.PageURLID != 0 {
+			if seen[result.PageURLID] {
+				continue
+			}
+			seen[result.PageURLID] = true
+		}
+		fingerprint, err := strconv.ParseUint(result.SimHash, 16, 64)
+		if err != nil {
+			continue
+		}
+		pages = append(pages, result)
+		fingerprints = append(fingerprints, fingerprint)
+	}
+	report.Compared = len(pages)
+
+	// Union every pair of pages within the allowed distance
+	parent := make([]int, len(pages))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i := range pages {
+		for j := i + 1; j < len(pages); j++ {
+			if bits.OnesCount64(fingerprints[i]^fingerprints[j]) <= report.MaxDistance {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	members := make(map[int][]int)
+	for i := range pages {
+		root := find(i)
+		members[root] = append(members[root], i)
+	}
+	for _, indexes := range members {
+		if len(indexes) < 2 {
+			continue
+		}
+		sort.Slice(indexes, func(a, b int) bool { return pages[indexes[a]].ID < pages[indexes[b]].ID })
+		first := fingerprints[indexes[0]]
+		cluster := Cluster{Size: len(indexes)}
+		for _, i := range indexes {
+			distance := bits.OnesCount64(first ^ fingerprints[i])
+			cluster.Pages = append(cluster.Pages, Page{
+				ResultID:   pages[i].ID,
+				PageURLID:  pages[i].PageURLID,
+				URL:        pages[i].URL,
+				Title:      pages[i].Title,
+				WordCount:  pages[i].WordCount,
+				CrawledAt:  pages[i].CreatedAt,
+				Similarity: math.Round((1-float64(distance)/fingerprintBits)*1000) / 1000,
+			})
+		}
+		report.Clusters = append(report.Clusters, cluster)
+	}
+
+	// Largest clusters first, then in order of their first result
+	sort.Slice(report.Clusters, func(a, b int) bool {
+		ca, cb := report.Clusters[a], report.Clusters[b]
+		if ca.Size != cb.Size {
+			return ca.Size > cb.Size
+		}
+		return ca.Pages[0].ResultID < cb.Pages[0].ResultID
+	})
+	return report
+}
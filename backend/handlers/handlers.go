@@ -1,29 +1,51 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
+	"url_analyzer/backend/changes"
+	"url_analyzer/backend/crawler"
+	"url_analyzer/backend/duplicates"
+	"url_analyzer/backend/models"
 	"url_analyzer/backend/repository"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // Handler manages HTTP request handling for crawl operations.
 type Handler struct {
-	repo *repository.DBRepository
+	repo    *repository.DBRepository
+	crawler *crawler.Crawler
 }
 
-// NewHandler creates a new Handler with the provided repository.
-func NewHandler(repo *repository.DBRepository) *Handler {
-	return &Handler{repo: repo}
+// NewHandler creates a new Handler with the provided repository and crawler.
+func NewHandler(repo *repository.DBRepository, crawler *crawler.Crawler) *Handler {
+	return &Handler{repo: repo, crawler: crawler}
 }
 
 // SubmitURL handles the submission of a URL for crawling.
 func (h *Handler) SubmitURL(c *gin.Context) {
 	var request struct {
-		URL string `json:"url" binding:"required,url"`
+		URL                string            `json:"url" binding:"required,url"`
+		MaxDepth           int               `json:"max_depth" binding:"min=0"`
+		MaxPages           int               `json:"max_pages" binding:"min=0"`
+		IgnoreRobots       bool              `json:"ignore_robots"`
+		CheckResourceSizes bool              `json:"check_resource_sizes"`
+		StoreText          bool              `json:"store_text"`
+		UserAgent          string            `json:"user_agent"`
+		Headers            map[string]string `json:"headers"`
+		Cookies            map[string]string `json:"cookies"`
+		AcceptLanguage     string            `json:"accept_language"`
+		TimeoutSeconds     int               `json:"timeout_seconds" binding:"min=0"`
+		ApplyToLinks       bool              `json:"apply_to_links"`
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -31,8 +53,42 @@ func (h *Handler) SubmitURL(c *gin.Context) {
 		return
 	}
 
+	if request.MaxDepth > crawler.MaxSiteDepth {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("max_depth must not exceed %d", crawler.MaxSiteDepth)})
+		return
+	}
+	if request.MaxPages > crawler.MaxSitePages {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("max_pages must not exceed %d", crawler.MaxSitePages)})
+		return
+	}
+
+	input := models.CrawlRequest{
+		URL:                request.URL,
+		MaxDepth:           request.MaxDepth,
+		MaxPages:           request.MaxPages,
+		IgnoreRobots:       request.IgnoreRobots,
+		CheckResourceSizes: request.CheckResourceSizes,
+		StoreText:          request.StoreText,
+		UserAgent:          request.UserAgent,
+		RequestHeaders:     request.Headers,
+		Cookies:            request.Cookies,
+		AcceptLanguage:     request.AcceptLanguage,
+		TimeoutSeconds:     request.TimeoutSeconds,
+		ApplyToLinks:       request.ApplyToLinks,
+	}
+	if err := crawler.ValidateRequestOptions(crawler.Options{
+		UserAgent:      request.UserAgent,
+		Header:         request.Headers,
+		Cookies:        request.Cookies,
+		AcceptLanguage: request.AcceptLanguage,
+		Timeout:        time.Duration(request.TimeoutSeconds) * time.Second,
+	}); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	ctx := c.Request.Context()
-	crawlRequest, err := h.repo.CreateCrawlRequest(ctx, request.URL)
+	crawlRequest, err := h.repo.CreateCrawlRequest(ctx, input)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create crawl request"})
 		return
@@ -44,6 +100,64 @@ func (h *Handler) SubmitURL(c *gin.Context) {
 	})
 }
 
+// ImportSitemap reads the sitemaps of a site, or a given sitemap URL, reports
+// sitemap problems and optionally queues every listed URL for crawling.
+func (h *Handler) ImportSitemap(c *gin.Context) {
+	var request struct {
+		URL          string `json:"url" binding:"required,url"`
+		Enqueue      bool   `json:"enqueue"`
+		CheckStatus  bool   `json:"check_status"`
+		IgnoreRobots bool   `json:"ignore_robots"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid URL format"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	sitemaps := []string{request.URL}
+	if !crawler.IsSitemapURL(request.URL) {
+		discovered, err := h.crawler.DiscoverSitemaps(ctx, request.URL)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "failed to discover sitemaps: " + err.Error()})
+			return
+		}
+		sitemaps = discovered
+	}
+
+	report := h.crawler.ReadSitemaps(ctx, sitemaps, crawler.SitemapOptions{
+		Options:     crawler.Options{IgnoreRobots: request.IgnoreRobots},
+		CheckStatus: request.CheckStatus,
+	})
+
+	enqueued := 0
+	if request.Enqueue {
+		inputs := make([]models.CrawlRequest, 0, len(report.URLs))
+		for _, entry := range report.URLs {
+			inputs = append(inputs, models.CrawlRequest{URL: entry.Loc, IgnoreRobots: request.IgnoreRobots})
+		}
+		created, err := h.repo.CreateCrawlRequests(ctx, inputs)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create crawl requests"})
+			return
+		}
+		enqueued = len(created)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"sitemaps":  report.Sitemaps,
+			"url_count": len(report.URLs),
+			"urls":      report.URLs,
+			"issues":    report.Issues,
+			"checked":   report.Checked,
+			"enqueued":  enqueued,
+		},
+		"message": "Sitemaps processed successfully",
+	})
+}
+
 // GetResults handles retrieval of paginated crawl results.
 func (h *Handler) GetResults(c *gin.Context) {
 	page, err := parseQueryInt(c, "page", repository.DefaultPage)
@@ -58,8 +172,18 @@ func (h *Handler) GetResults(c *gin.Context) {
 		return
 	}
 
+	filter := repository.ResultFilter{Language: strings.ToLower(c.Query("language"))}
+	if value := c.Query("language_mismatch"); value != "" {
+		mismatch, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid language_mismatch parameter"})
+			return
+		}
+		filter.LanguageMismatch = &mismatch
+	}
+
 	ctx := c.Request.Context()
-	results, totalItems, totalPages, err := h.repo.GetPaginatedResults(ctx, page, pageSize)
+	results, totalItems, totalPages, err := h.repo.GetPaginatedResults(ctx, filter, page, pageSize)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch results"})
 		return
@@ -79,6 +203,230 @@ func (h *Handler) GetResults(c *gin.Context) {
 	})
 }
 
+// GetResultLinks handles retrieval of the paginated links of a crawl result,
+// optionally filtered by status.
+func (h *Handler) GetResultLinks(c *gin.Context) {
+	resultID, err := parseParamID(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid result ID"})
+		return
+	}
+
+	page, err := parseQueryInt(c, "page", repository.DefaultPage)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid page parameter"})
+		return
+	}
+
+	pageSize, err := parseQueryInt(c, "pageSize", repository.DefaultPageSize)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pageSize parameter"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if _, err := h.repo.GetCrawlResult(ctx, resultID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "result not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch result"})
+		return
+	}
+
+	links, totalItems, totalPages, err := h.repo.GetResultLinks(ctx, resultID, c.Query("status"), page, pageSize)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidLinkFilter) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status parameter"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch links"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": links,
+		"pagination": gin.H{
+			"currentPage": page,
+			"pageSize":    pageSize,
+			"totalItems":  totalItems,
+			"totalPages":  totalPages,
+			"hasNext":     page < int(totalPages),
+			"hasPrev":     page > 1,
+		},
+		"message": "Links fetched successfully",
+	})
+}
+
+// GetResultArchive handles the download of the WARC file holding the raw
+// exchanges of a result's crawl request.
+func (h *Handler) GetResultArchive(c *gin.Context) {
+	resultID, err := parseParamID(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid result ID"})
+		return
+	}
+
+	result, err := h.repo.GetCrawlResult(c.Request.Context(), resultID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "result not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch result"})
+		return
+	}
+
+	if result.ArchiveRecordID == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "result has no archive"})
+		return
+	}
+	path := h.crawler.ArchivePath(result.CrawlRequestID)
+	if _, err := os.Stat(path); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "archive not found"})
+		return
+	}
+
+	c.Header("Content-Type", "application/warc")
+	c.FileAttachment(path, filepath.Base(path))
+}
+
+// GetURLs handles retrieval of the paginated page URL groups that crawl
+// results are grouped by.
+func (h *Handler) GetURLs(c *gin.Context) {
+	page, err := parseQueryInt(c, "page", repository.DefaultPage)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid page parameter"})
+		return
+	}
+
+	pageSize, err := parseQueryInt(c, "pageSize", repository.DefaultPageSize)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pageSize parameter"})
+		return
+	}
+
+	pageURLs, totalItems, totalPages, err := h.repo.GetPageURLs(c.Request.Context(), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch URLs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": pageURLs,
+		"pagination": gin.H{
+			"currentPage": page,
+			"pageSize":    pageSize,
+			"totalItems":  totalItems,
+			"totalPages":  totalPages,
+			"hasNext":     page < int(totalPages),
+			"hasPrev":     page > 1,
+		},
+		"message": "URLs fetched successfully",
+	})
+}
+
+// GetURLDiff handles the comparison of two crawl results of a page URL
+// group. The from and to query parameters select the results; without them
+// the two most recent results are compared.
+func (h *Handler) GetURLDiff(c *gin.Context) {
+	pageURLID, err := parseParamID(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid URL ID"})
+		return
+	}
+
+	fromParam, toParam := c.Query("from"), c.Query("to")
+	if (fromParam == "") != (toParam == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to must be given together"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	pageURL, err := h.repo.GetPageURL(ctx, pageURLID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "URL not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch URL"})
+		return
+	}
+
+	var from, to *models.CrawlResult
+	if fromParam == "" {
+		latest, err := h.repo.GetLatestURLSnapshots(ctx, pageURLID, 2)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch results"})
+			return
+		}
+		if len(latest) < 2 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "URL has fewer than two results"})
+			return
+		}
+		from, to = &latest[1], &latest[0]
+	} else {
+		fromID, errFrom := strconv.ParseUint(fromParam, 10, 64)
+		toID, errTo := strconv.ParseUint(toParam, 10, 64)
+		if errFrom != nil || errTo != nil || fromID == 0 || toID == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from or to parameter"})
+			return
+		}
+		from, err = h.repo.GetURLSnapshot(ctx, pageURLID, uint(fromID))
+		if err == nil {
+			to, err = h.repo.GetURLSnapshot(ctx, pageURLID, uint(toID))
+		}
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "result not found for URL"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch result"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    changes.Compare(pageURL, from, to),
+		"message": "Diff computed successfully",
+	})
+}
+
+// GetDuplicates handles the listing of clusters of near-duplicate pages among
+// the latest results of every URL. The threshold query parameter sets the
+// minimum similarity of duplicates.
+func (h *Handler) GetDuplicates(c *gin.Context) {
+	threshold := duplicates.DefaultThreshold
+	if value := c.Query("threshold"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < duplicates.MinThreshold || parsed > 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("threshold must be between %g and 1", duplicates.MinThreshold)})
+			return
+		}
+		threshold = parsed
+	}
+
+	results, err := h.repo.GetFingerprintedResults(c.Request.Context(), duplicates.MaxResults)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch results"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    duplicates.Find(results, threshold),
+		"message": "Duplicates fetched successfully",
+	})
+}
+
+// parseParamID parses a positive integer ID path parameter.
+func parseParamID(c *gin.Context, key string) (uint, error) {
+	id, err := strconv.ParseUint(c.Param(key), 10, 64)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid %s parameter", key)
+	}
+	return uint(id), nil
+}
+
 // parseQueryInt parses an integer query parameter with a default value.
 func parseQueryInt(c *gin.Context, key string, defaultValue int) (int, error) {
 	valueStr := c.DefaultQuery(key, strconv.Itoa(defaultValue))
@@ -3,35 +3,114 @@ package models
 import (
 	"time"
 
+	"url_analyzer/backend/crawler"
+
 	"gorm.io/gorm"
 )
 
 type CrawlRequest struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	URL       string    `json:"url" gorm:"not null"`
-	Status    string    `json:"status"` // queued, running, done, error
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID                 uint              `json:"id" gorm:"primaryKey"`
+	URL                string            `json:"url" gorm:"not null"`
+	Status             string            `json:"status"`                                 // queued, running, done, error
+	MaxDepth           int               `json:"max_depth"`                              // 0 analyzes only the submitted page
+	MaxPages           int               `json:"max_pages"`                              // page limit for site crawls
+	IgnoreRobots       bool              `json:"ignore_robots"`                          // skip robots.txt for sites the user owns
+	CheckResourceSizes bool              `json:"check_resource_sizes"`                   // request subresources with HEAD for page weight
+	StoreText          bool              `json:"store_text"`                             // keep the extracted main text on results
+	UserAgent          string            `json:"user_agent"`                             // overrides the crawler's User-Agent
+	RequestHeaders     map[string]string `json:"request_headers" gorm:"serializer:json"` // extra headers for the page request
+	Cookies            map[string]string `json:"-" gorm:"serializer:json"`               // not returned, as cookies often hold sessions
+	AcceptLanguage     string            `json:"accept_language"`
+	TimeoutSeconds     int               `json:"timeout_seconds"` // page request timeout, 0 for the default
+	ApplyToLinks       bool              `json:"apply_to_links"`  // also use the request settings for link checks
+	CreatedAt          time.Time         `json:"created_at"`
+	UpdatedAt          time.Time         `json:"updated_at"`
 }
 
 type CrawlResult struct {
-	ID             uint         `json:"id" gorm:"primaryKey"`
-	CrawlRequestID uint         `json:"crawl_request_id" gorm:"not null"`
-	CrawlRequest   CrawlRequest `json:"-"`
-	HTMLVersion    string       `json:"html_version"`
-	Title          string       `json:"title"`
-	H1Count        int          `json:"h1_count"`
-	H2Count        int          `json:"h2_count"`
-	H3Count        int          `json:"h3_count"`
-	H4Count        int          `json:"h4_count"`
-	H5Count        int          `json:"h5_count"`
-	H6Count        int          `json:"h6_count"`
-	InternalLinks  int          `json:"internal_links"`
-	ExternalLinks  int          `json:"external_links"`
-	BrokenLinks    int          `json:"broken_links"`
-	HasLoginForm   bool         `json:"has_login_form"`
-	ProcessingTime float64      `json:"processing_time"` // in seconds
-	CreatedAt      time.Time    `json:"created_at"`
+	ID                    uint                       `json:"id" gorm:"primaryKey"`
+	CrawlRequestID        uint                       `json:"crawl_request_id" gorm:"not null"`
+	CrawlRequest          CrawlRequest               `json:"-"`
+	PageURLID             uint                       `json:"page_url_id" gorm:"index"` // group of results sharing a normalized URL
+	URL                   string                     `json:"url"`
+	FinalURL              string                     `json:"final_url"`   // URL after following redirects
+	StatusCode            int                        `json:"status_code"` // status of the final response; other than 200 leaves the page unanalyzed
+	MediaType             string                     `json:"media_type"`
+	Size                  int64                      `json:"size"`                            // body size in bytes
+	Truncated             bool                       `json:"truncated"`                       // only the start of an oversized body was analyzed
+	ResourceType          string                     `json:"resource_type"`                   // html, pdf, image, json or other
+	Resource              *crawler.ResourceInfo      `json:"resource" gorm:"serializer:json"` // analysis of non-HTML targets
+	ArchiveRecordID       string                     `json:"archive_record_id"`               // WARC-Record-ID of the archived page response
+	Depth                 int                        `json:"depth"`                           // link depth from the submitted URL
+	HTMLVersion           string                     `json:"html_version"`
+	Encoding              *crawler.EncodingInfo      `json:"encoding" gorm:"serializer:json"`
+	Title                 string                     `json:"title"`
+	H1Count               int                        `json:"h1_count"`
+	H2Count               int                        `json:"h2_count"`
+	H3Count               int                        `json:"h3_count"`
+	H4Count               int                        `json:"h4_count"`
+	H5Count               int                        `json:"h5_count"`
+	H6Count               int                        `json:"h6_count"`
+	InternalLinks         int                        `json:"internal_links"`
+	ExternalLinks         int                        `json:"external_links"`
+	BrokenLinks           int                        `json:"broken_links"`
+	HasLoginForm          bool                       `json:"has_login_form"` // derived from forms
+	Forms                 []crawler.FormInfo         `json:"forms" gorm:"serializer:json"`
+	RobotsSkippedLinks    []string                   `json:"robots_skipped_links" gorm:"serializer:json"` // links left unchecked due to robots.txt
+	RedirectChain         []crawler.RedirectHop      `json:"redirect_chain" gorm:"serializer:json"`
+	RedirectChainTooLong  bool                       `json:"redirect_chain_too_long"`
+	RedirectLoop          bool                       `json:"redirect_loop"`
+	SEO                   *crawler.SEOData           `json:"seo" gorm:"serializer:json"`
+	AccessibilityFindings []crawler.Finding          `json:"accessibility_findings" gorm:"serializer:json"`
+	Outline               *crawler.HeadingOutline    `json:"outline" gorm:"serializer:json"`
+	StructuredData        *crawler.StructuredData    `json:"structured_data" gorm:"serializer:json"`
+	Resources             *crawler.ResourceInventory `json:"resources" gorm:"serializer:json"`
+	TLS                   *crawler.TLSInfo           `json:"tls" gorm:"serializer:json"`
+	MixedContent          []crawler.MixedContent     `json:"mixed_content" gorm:"serializer:json"`
+	SecurityGrade         string                     `json:"security_grade" gorm:"size:1"` // overall security header grade, A-F
+	SecurityHeaders       *crawler.SecurityHeaders   `json:"security_headers" gorm:"serializer:json"`
+	ResponseHeaders       map[string]string          `json:"response_headers" gorm:"serializer:json"`
+	Text                  string                     `json:"-" gorm:"type:mediumtext"` // visible page text, kept for diffs
+	WordCount             int                        `json:"word_count"`               // words of the main text
+	Readability           *crawler.Readability       `json:"readability" gorm:"serializer:json"`
+	MainText              string                     `json:"main_text,omitempty" gorm:"type:mediumtext"` // only kept when requested
+	Language              string                     `json:"language" gorm:"size:8;index"`               // detected ISO 639-1 code, empty when undetermined
+	LanguageConfidence    float64                    `json:"language_confidence"`
+	LanguageMismatch      bool                       `json:"language_mismatch" gorm:"index"` // declared language differs from the detected one
+	LanguageInfo          *crawler.LanguageInfo      `json:"language_info" gorm:"serializer:json"`
+	SimHash               string                     `json:"simhash" gorm:"size:16"` // main text fingerprint, empty for too little text
+	ProcessingTime        float64                    `json:"processing_time"`        // in seconds
+	CreatedAt             time.Time                  `json:"created_at"`
+	Links                 []CrawlLink                `json:"-"`
+}
+
+// PageURL groups the crawl results of a page by its normalized URL.
+type PageURL struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	URL           string     `json:"url" gorm:"type:text;not null"`         // normalized URL
+	Hash          string     `json:"-" gorm:"size:64;not null;uniqueIndex"` // SHA-256 of URL, as URLs are too long to index
+	ResultCount   int64      `json:"result_count" gorm:"->;-:migration"`    // filled when listing
+	LastCrawledAt *time.Time `json:"last_crawled_at" gorm:"->;-:migration"` // filled when listing
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type CrawlLink struct {
+	ID                   uint                  `json:"id" gorm:"primaryKey"`
+	CrawlResultID        uint                  `json:"crawl_result_id" gorm:"not null;index"`
+	Href                 string                `json:"href" gorm:"type:text"`
+	URL                  string                `json:"url" gorm:"type:text"` // href resolved against the page URL
+	AnchorText           string                `json:"anchor_text" gorm:"type:text"`
+	Rel                  string                `json:"rel"`
+	Internal             bool                  `json:"internal"`
+	Checked              bool                  `json:"checked"`
+	Broken               bool                  `json:"broken"`
+	StatusCode           int                   `json:"status_code"`
+	ErrorType            string                `json:"error_type"` // client_error, server_error, dns_failure, timeout, tls_error, connection_error, redirect_error
+	Error                string                `json:"error" gorm:"type:text"`
+	RedirectURL          string                `json:"redirect_url" gorm:"type:text"`
+	RedirectChain        []crawler.RedirectHop `json:"redirect_chain" gorm:"serializer:json"`
+	RedirectChainTooLong bool                  `json:"redirect_chain_too_long"`
+	RedirectLoop         bool                  `json:"redirect_loop"`
 }
 
 type User struct {
@@ -2,9 +2,14 @@ package repository
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
+	"url_analyzer/backend/crawler"
 	"url_analyzer/backend/models"
 
 	"gorm.io/gorm"
@@ -19,6 +24,24 @@ const (
 	StatusFailed     = "failed"
 )
 
+// Link status filters for GetResultLinks
+const (
+	LinkFilterBroken    = "broken"
+	LinkFilterOK        = "ok"
+	LinkFilterRedirect  = "redirected"
+	LinkFilterUnchecked = "unchecked"
+)
+
+// ResultFilter narrows down the results returned by GetPaginatedResults.
+// Zero values match every result.
+type ResultFilter struct {
+	Language         string // detected language code
+	LanguageMismatch *bool  // whether a declared language differs from the detected one
+}
+
+// ErrInvalidLinkFilter is returned for an unknown link status filter.
+var ErrInvalidLinkFilter = errors.New("invalid link status filter")
+
 // Pagination constants
 const (
 	DefaultPage     = 1
@@ -36,18 +59,36 @@ func NewDBRepository(db *gorm.DB) *DBRepository {
 	return &DBRepository{DB: db}
 }
 
-// CreateCrawlRequest creates a new crawl request with the given URL.
-func (r *DBRepository) CreateCrawlRequest(ctx context.Context, url string) (*models.CrawlRequest, error) {
-	request := &models.CrawlRequest{
-		URL:       url,
-		Status:    StatusQueued,
-		CreatedAt: time.Now(),
-	}
+// CreateCrawlRequest queues a new crawl request using the URL and crawl
+// options of the given request.
+func (r *DBRepository) CreateCrawlRequest(ctx context.Context, input models.CrawlRequest) (*models.CrawlRequest, error) {
+	request := &input
+	request.ID = 0
+	request.Status = StatusQueued
+	request.CreatedAt = time.Now()
 	return request, r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		return tx.Create(request).Error
 	})
 }
 
+// CreateCrawlRequests queues several crawl requests in a single transaction.
+func (r *DBRepository) CreateCrawlRequests(ctx context.Context, inputs []models.CrawlRequest) ([]models.CrawlRequest, error) {
+	requests := make([]models.CrawlRequest, len(inputs))
+	now := time.Now()
+	for i, input := range inputs {
+		input.ID = 0
+		input.Status = StatusQueued
+		input.CreatedAt = now
+		requests[i] = input
+	}
+	if len(requests) == 0 {
+		return requests, nil
+	}
+	return requests, r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.CreateInBatches(&requests, 500).Error
+	})
+}
+
 // GetCrawlRequest retrieves a crawl request by ID.
 func (r *DBRepository) GetCrawlRequest(ctx context.Context, id uint) (*models.CrawlRequest, error) {
 	var request models.CrawlRequest
@@ -67,15 +108,39 @@ func (r *DBRepository) UpdateCrawlRequestStatus(ctx context.Context, id uint, st
 	return nil
 }
 
-// SaveCrawlResult saves a crawl result to the database.
+// SaveCrawlResult saves a crawl result to the database, assigning it to the
+// page URL group of its normalized URL.
 func (r *DBRepository) SaveCrawlResult(ctx context.Context, result *models.CrawlResult) error {
 	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		pageURL, err := findOrCreatePageURL(tx, result.URL)
+		if err != nil {
+			return err
+		}
+		result.PageURLID = pageURL.ID
 		return tx.Create(result).Error
 	})
 }
 
-// GetPaginatedResults retrieves paginated crawl results with metadata.
-func (r *DBRepository) GetPaginatedResults(ctx context.Context, page, pageSize int) ([]models.CrawlResult, int64, int64, error) {
+// findOrCreatePageURL returns the page URL group of a URL, creating it when
+// no result has been saved for the normalized URL yet.
+func findOrCreatePageURL(tx *gorm.DB, rawURL string) (*models.PageURL, error) {
+	normalized := crawler.NormalizeURL(rawURL)
+	sum := sha256.Sum256([]byte(normalized))
+	pageURL := models.PageURL{URL: normalized, Hash: hex.EncodeToString(sum[:]), CreatedAt: time.Now()}
+
+	// Concurrent workers may create the same group, so conflicts are ignored
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&pageURL).Error; err != nil {
+		return nil, fmt.Errorf("failed to create page URL %s: %w", normalized, err)
+	}
+	if err := tx.Where("hash = ?", pageURL.Hash).First(&pageURL).Error; err != nil {
+		return nil, fmt.Errorf("failed to get page URL %s: %w", normalized, err)
+	}
+	return &pageURL, nil
+}
+
+// GetPaginatedResults retrieves paginated crawl results matching the filter
+// with metadata.
+func (r *DBRepository) GetPaginatedResults(ctx context.Context, filter ResultFilter, page, pageSize int) ([]models.CrawlResult, int64, int64, error) {
 	// Validate pagination parameters
 	if page < 1 {
 		page = DefaultPage
@@ -87,8 +152,16 @@ func (r *DBRepository) GetPaginatedResults(ctx context.Context, page, pageSize i
 	var results []models.CrawlResult
 	var totalItems int64
 
+	query := r.DB.WithContext(ctx).Model(&models.CrawlResult{})
+	if filter.Language != "" {
+		query = query.Where("language = ?", filter.Language)
+	}
+	if filter.LanguageMismatch != nil {
+		query = query.Where("language_mismatch = ?", *filter.LanguageMismatch)
+	}
+
 	// Count total records
-	if err := r.DB.WithContext(ctx).Model(&models.CrawlResult{}).Count(&totalItems).Error; err != nil {
+	if err := query.Count(&totalItems).Error; err != nil {
 		return nil, 0, 0, fmt.Errorf("failed to count crawl results: %w", err)
 	}
 
@@ -100,8 +173,8 @@ func (r *DBRepository) GetPaginatedResults(ctx context.Context, page, pageSize i
 
 	// Get paginated results
 	offset := (page - 1) * pageSize
-	if err := r.DB.WithContext(ctx).
-		Preload(clause.Associations).
+	if err := query.
+		Preload("CrawlRequest").
 		Offset(offset).
 		Limit(pageSize).
 		Order("created_at DESC").
@@ -111,3 +184,157 @@ func (r *DBRepository) GetPaginatedResults(ctx context.Context, page, pageSize i
 
 	return results, totalItems, totalPages, nil
 }
+
+// GetCrawlResult retrieves a crawl result by ID.
+func (r *DBRepository) GetCrawlResult(ctx context.Context, id uint) (*models.CrawlResult, error) {
+	var result models.CrawlResult
+	if err := r.DB.WithContext(ctx).First(&result, id).Error; err != nil {
+		return nil, fmt.Errorf("failed to get crawl result with ID %d: %w", id, err)
+	}
+	return &result, nil
+}
+
+// GetResultLinks retrieves paginated links of a crawl result. status filters by
+// one of the LinkFilter constants, a link error type such as "dns_failure", or
+// a numeric HTTP status code; an empty status returns every link.
+func (r *DBRepository) GetResultLinks(ctx context.Context, resultID uint, status string, page, pageSize int) ([]models.CrawlLink, int64, int64, error) {
+	if page < 1 {
+		page = DefaultPage
+	}
+	if pageSize < 1 || pageSize > MaxPageSize {
+		pageSize = DefaultPageSize
+	}
+
+	query := r.DB.WithContext(ctx).Model(&models.CrawlLink{}).Where("crawl_result_id = ?", resultID)
+	switch status {
+	case "":
+	case LinkFilterBroken:
+		query = query.Where("broken = ?", true)
+	case LinkFilterOK:
+		query = query.Where("checked = ? AND broken = ?", true, false)
+	case LinkFilterRedirect:
+		query = query.Where("redirect_url <> ''")
+	case LinkFilterUnchecked:
+		query = query.Where("checked = ?", false)
+	case crawler.LinkErrorClient, crawler.LinkErrorServer, crawler.LinkErrorDNS,
+		crawler.LinkErrorTimeout, crawler.LinkErrorTLS, crawler.LinkErrorConnection, crawler.LinkErrorRedirect:
+		query = query.Where("error_type = ?", status)
+	default:
+		code, err := strconv.Atoi(status)
+		if err != nil {
+			return nil, 0, 0, fmt.Errorf("%w: %q", ErrInvalidLinkFilter, status)
+		}
+		query = query.Where("status_code = ?", code)
+	}
+
+	var totalItems int64
+	if err := query.Count(&totalItems).Error; err != nil {
+		return nil, 0, 0, fmt.Errorf("failed to count links for result ID %d: %w", resultID, err)
+	}
+
+	totalPages := totalItems / int64(pageSize)
+	if totalItems%int64(pageSize) != 0 {
+		totalPages++
+	}
+
+	var links []models.CrawlLink
+	offset := (page - 1) * pageSize
+	if err := query.
+		Offset(offset).
+		Limit(pageSize).
+		Order("id ASC").
+		Find(&links).Error; err != nil {
+		return nil, 0, 0, fmt.Errorf("failed to fetch links for result ID %d: %w", resultID, err)
+	}
+
+	return links, totalItems, totalPages, nil
+}
+
+// GetPageURLs retrieves paginated page URL groups with their number of
+// results, most recently crawled first.
+func (r *DBRepository) GetPageURLs(ctx context.Context, page, pageSize int) ([]models.PageURL, int64, int64, error) {
+	if page < 1 {
+		page = DefaultPage
+	}
+	if pageSize < 1 || pageSize > MaxPageSize {
+		pageSize = DefaultPageSize
+	}
+
+	var totalItems int64
+	if err := r.DB.WithContext(ctx).Model(&models.PageURL{}).Count(&totalItems).Error; err != nil {
+		return nil, 0, 0, fmt.Errorf("failed to count page URLs: %w", err)
+	}
+
+	totalPages := totalItems / int64(pageSize)
+	if totalItems%int64(pageSize) != 0 {
+		totalPages++
+	}
+
+	var pageURLs []models.PageURL
+	offset := (page - 1) * pageSize
+	if err := r.DB.WithContext(ctx).
+		Model(&models.PageURL{}).
+		Select("page_urls.*, COUNT(crawl_results.id) AS result_count, MAX(crawl_results.created_at) AS last_crawled_at").
+		Joins("LEFT JOIN crawl_results ON crawl_results.page_url_id = page_urls.id").
+		Group("page_urls.id").
+		Order("last_crawled_at DESC").
+		Offset(offset).
+		Limit(pageSize).
+		Find(&pageURLs).Error; err != nil {
+		return nil, 0, 0, fmt.Errorf("failed to fetch page URLs: %w", err)
+	}
+
+	return pageURLs, totalItems, totalPages, nil
+}
+
+// GetPageURL retrieves a page URL group by ID.
+func (r *DBRepository) GetPageURL(ctx context.Context, id uint) (*models.PageURL, error) {
+	var pageURL models.PageURL
+	if err := r.DB.WithContext(ctx).First(&pageURL, id).Error; err != nil {
+		return nil, fmt.Errorf("failed to get page URL with ID %d: %w", id, err)
+	}
+	return &pageURL, nil
+}
+
+// GetURLSnapshot retrieves a crawl result of a page URL group with its links.
+func (r *DBRepository) GetURLSnapshot(ctx context.Context, pageURLID, resultID uint) (*models.CrawlResult, error) {
+	var result models.CrawlResult
+	if err := r.DB.WithContext(ctx).
+		Preload("Links").
+		Where("page_url_id = ?", pageURLID).
+		First(&result, resultID).Error; err != nil {
+		return nil, fmt.Errorf("failed to get result %d of page URL %d: %w", resultID, pageURLID, err)
+	}
+	return &result, nil
+}
+
+// GetLatestURLSnapshots retrieves up to limit of the newest crawl results of a
+// page URL group with their links, newest first.
+func (r *DBRepository) GetLatestURLSnapshots(ctx context.Context, pageURLID uint, limit int) ([]models.CrawlResult, error) {
+	var results []models.CrawlResult
+	if err := r.DB.WithContext(ctx).
+		Preload("Links").
+		Where("page_url_id = ?", pageURLID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&results).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch results of page URL %d: %w", pageURLID, err)
+	}
+	return results, nil
+}
+
+// GetFingerprintedResults retrieves up to limit of the newest results that
+// have a text fingerprint, newest first. Only the columns needed to compare
+// fingerprints are loaded.
+func (r *DBRepository) GetFingerprintedResults(ctx context.Context, limit int) ([]models.CrawlResult, error) {
+	var results []models.CrawlResult
+	if err := r.DB.WithContext(ctx).
+		Select("id", "crawl_request_id", "page_url_id", "url", "final_url", "title", "word_count", "sim_hash", "created_at").
+		Where("sim_hash <> ''").
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&results).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch fingerprinted results: %w", err)
+	}
+	return results, nil
+}
@@ -25,7 +25,8 @@ type Worker struct {
 
 // Config holds worker configuration settings.
 type Config struct {
-	PollInterval time.Duration // Interval to check for new queued requests
+	PollInterval time.Duration   // Interval to check for new queued requests
+	Crawler      *crawler.Config // Crawler settings; nil uses the crawler defaults
 }
 
 // NewWorker creates a new Worker with the provided repository and default configuration.
@@ -38,7 +39,7 @@ func NewWorker(repo *repository.DBRepository, cfg *Config) *Worker {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &Worker{
 		repo:    repo,
-		crawler: crawler.NewCrawler(),
+		crawler: crawler.NewCrawler(cfg.Crawler),
 		ctx:     ctx,
 		cancel:  cancel,
 	}
@@ -99,41 +100,16 @@ func (w *Worker) processRequest(ctx context.Context, request *models.CrawlReques
 		return fmt.Errorf("failed to update status to processing for request ID %d: %w", request.ID, err)
 	}
 
-	// Crawl the URL
-	data, err := w.crawler.Crawl(request.URL)
-	if err != nil {
-		log.Error().Err(err).Str("url", request.URL).Msg("Failed to crawl URL")
-		if updateErr := w.repo.UpdateCrawlRequestStatus(ctx, request.ID, repository.StatusFailed); updateErr != nil {
-			log.Error().Err(updateErr).Uint("request_id", request.ID).Msg("Failed to update status to failed")
-		}
-		return fmt.Errorf("failed to crawl URL %s: %w", request.URL, err)
+	// Crawl the URL, or the whole site when a depth was requested
+	process := w.processSinglePage
+	if request.MaxDepth > 0 {
+		process = w.processSiteCrawl
 	}
-
-	// Save results
-	result := &models.CrawlResult{
-		CrawlRequestID: request.ID,
-		HTMLVersion:    data.HTMLVersion,
-		Title:          data.Title,
-		H1Count:        data.H1Count,
-		H2Count:        data.H2Count,
-		H3Count:        data.H3Count,
-		H4Count:        data.H4Count,
-		H5Count:        data.H5Count,
-		H6Count:        data.H6Count,
-		InternalLinks:  data.InternalLinks,
-		ExternalLinks:  data.ExternalLinks,
-		BrokenLinks:    data.BrokenLinks,
-		HasLoginForm:   data.HasLoginForm,
-		ProcessingTime: data.ProcessingTime,
-		CreatedAt:      time.Now(),
-	}
-
-	if err := w.repo.SaveCrawlResult(ctx, result); err != nil {
-		log.Error().Err(err).Str("url", request.URL).Msg("Failed to save crawl result")
+	if err := process(ctx, request); err != nil {
 		if updateErr := w.repo.UpdateCrawlRequestStatus(ctx, request.ID, repository.StatusFailed); updateErr != nil {
 			log.Error().Err(updateErr).Uint("request_id", request.ID).Msg("Failed to update status to failed")
 		}
-		return fmt.Errorf("failed to save crawl result for URL %s: %w", request.URL, err)
+		return err
 	}
 
 	// Update status to completed
@@ -148,3 +124,169 @@ func (w *Worker) processRequest(ctx context.Context, request *models.CrawlReques
 		Msg("Successfully processed crawl request")
 	return nil
 }
+
+// processSinglePage crawls and saves the result for the request URL only.
+func (w *Worker) processSinglePage(ctx context.Context, request *models.CrawlRequest) error {
+	data, err := w.crawler.Crawl(ctx, request.URL, crawlOptions(request))
+	if err != nil {
+		log.Error().Err(err).Str("url", request.URL).Msg("Failed to crawl URL")
+		return fmt.Errorf("failed to crawl URL %s: %w", request.URL, err)
+	}
+
+	if err := w.repo.SaveCrawlResult(ctx, newCrawlResult(request, data, 0)); err != nil {
+		log.Error().Err(err).Str("url", request.URL).Msg("Failed to save crawl result")
+		return fmt.Errorf("failed to save crawl result for URL %s: %w", request.URL, err)
+	}
+	return nil
+}
+
+// processSiteCrawl walks the site starting at the request URL and saves a
+// result for every page visited. Pages that fail to crawl are skipped, but
+// the request fails if the start page itself cannot be crawled.
+func (w *Worker) processSiteCrawl(ctx context.Context, request *models.CrawlRequest) error {
+	opts := crawler.SiteOptions{
+		Options:  crawlOptions(request),
+		MaxDepth: request.MaxDepth,
+		MaxPages: request.MaxPages,
+	}
+
+	saved := 0
+	err := w.crawler.CrawlSite(ctx, request.URL, opts, func(page *crawler.SitePage) error {
+		if page.Err != nil {
+			if page.Depth == 0 {
+				return fmt.Errorf("failed to crawl URL %s: %w", request.URL, page.Err)
+			}
+			log.Warn().Err(page.Err).Str("url", page.URL).Uint("request_id", request.ID).Msg("Skipping page that failed to crawl")
+			return nil
+		}
+
+		if err := w.repo.SaveCrawlResult(ctx, newCrawlResult(request, page.Data, page.Depth)); err != nil {
+			return fmt.Errorf("failed to save crawl result for URL %s: %w", page.Data.URL, err)
+		}
+		saved++
+		return nil
+	})
+	if err != nil {
+		log.Error().Err(err).Str("url", request.URL).Msg("Failed to crawl site")
+		return err
+	}
+
+	log.Info().
+		Str("url", request.URL).
+		Uint("request_id", request.ID).
+		Int("pages", saved).
+		Msg("Site crawl finished")
+	return nil
+}
+
+// crawlOptions returns the crawl options requested by a crawl request.
+func crawlOptions(request *models.CrawlRequest) crawler.Options {
+	return crawler.Options{
+		IgnoreRobots:       request.IgnoreRobots,
+		CheckResourceSizes: request.CheckResourceSizes,
+		ArchiveID:          request.ID,
+		UserAgent:          request.UserAgent,
+		Header:             request.RequestHeaders,
+		Cookies:            request.Cookies,
+		AcceptLanguage:     request.AcceptLanguage,
+		Timeout:            time.Duration(request.TimeoutSeconds) * time.Second,
+		ApplyToLinks:       request.ApplyToLinks,
+	}
+}
+
+// newCrawlResult converts crawl data into a result row for the given request.
+func newCrawlResult(request *models.CrawlRequest, data *crawler.CrawlData, depth int) *models.CrawlResult {
+	result := &models.CrawlResult{
+		CrawlRequestID:  request.ID,
+		URL:             data.URL,
+		FinalURL:        data.FinalURL,
+		StatusCode:      data.StatusCode,
+		Depth:           depth,
+		MediaType:       data.MediaType,
+		Size:            data.Size,
+		Truncated:       data.Truncated,
+		ResourceType:    data.ResourceType,
+		ArchiveRecordID: data.ArchiveRecordID,
+		HTMLVersion:     data.HTMLVersion,
+		Title:           data.Title,
+		H1Count:         data.H1Count,
+		H2Count:         data.H2Count,
+		H3Count:         data.H3Count,
+		H4Count:         data.H4Count,
+		H5Count:         data.H5Count,
+		H6Count:         data.H6Count,
+		InternalLinks:   data.InternalLinks,
+		ExternalLinks:   data.ExternalLinks,
+		BrokenLinks:     data.BrokenLinks,
+		HasLoginForm:    data.HasLoginForm,
+		ProcessingTime:  data.ProcessingTime,
+		CreatedAt:       time.Now(),
+
+		RobotsSkippedLinks:    data.RobotsSkippedLinks,
+		RedirectChain:         data.RedirectChain,
+		RedirectChainTooLong:  data.RedirectChainTooLong,
+		RedirectLoop:          data.RedirectLoop,
+		Forms:                 data.Forms,
+		Resource:              data.Resource,
+		Encoding:              data.Encoding,
+		SEO:                   data.SEO,
+		AccessibilityFindings: data.AccessibilityFindings,
+		Outline:               data.Outline,
+		StructuredData:        data.StructuredData,
+		Resources:             data.Resources,
+		TLS:                   data.TLS,
+		MixedContent:          data.MixedContent,
+		SecurityGrade:         securityGrade(data.SecurityHeaders),
+		SecurityHeaders:       data.SecurityHeaders,
+		ResponseHeaders:       data.ResponseHeaders,
+		Text:                  data.Text,
+		Readability:           data.Readability,
+		LanguageInfo:          data.Language,
+		SimHash:               data.SimHash,
+		Links:                 newCrawlLinks(data.Links),
+	}
+	if data.Readability != nil {
+		result.WordCount = data.Readability.WordCount
+	}
+	if data.Language != nil {
+		result.Language = data.Language.Language
+		result.LanguageConfidence = data.Language.Confidence
+		result.LanguageMismatch = len(data.Language.Findings) > 0
+	}
+	if request.StoreText {
+		result.MainText = data.MainText
+	}
+	return result
+}
+
+// securityGrade returns the overall grade of the security headers, if any.
+func securityGrade(sec *crawler.SecurityHeaders) string {
+	if sec == nil {
+		return ""
+	}
+	return sec.Grade
+}
+
+// newCrawlLinks converts link details into link rows.
+func newCrawlLinks(details []crawler.LinkDetail) []models.CrawlLink {
+	links := make([]models.CrawlLink, 0, len(details))
+	for _, link := range details {
+		links = append(links, models.CrawlLink{
+			Href:                 link.Href,
+			URL:                  link.URL,
+			AnchorText:           link.AnchorText,
+			Rel:                  link.Rel,
+			Internal:             link.Internal,
+			Checked:              link.Checked,
+			Broken:               link.Broken,
+			StatusCode:           link.StatusCode,
+			ErrorType:            link.ErrorType,
+			Error:                link.Error,
+			RedirectURL:          link.RedirectURL,
+			RedirectChain:        link.RedirectChain,
+			RedirectChainTooLong: link.RedirectChainTooLong,
+			RedirectLoop:         link.RedirectLoop,
+		})
+	}
+	return links
+}
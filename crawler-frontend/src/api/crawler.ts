@@ -1,5 +1,16 @@
 interface CrawlRequest {
   url: string;
+  max_depth?: number;
+  max_pages?: number;
+  ignore_robots?: boolean;
+  check_resource_sizes?: boolean;
+  store_text?: boolean;
+  user_agent?: string;
+  headers?: Record<string, string>;
+  cookies?: Record<string, string>;
+  accept_language?: string;
+  timeout_seconds?: number;
+  apply_to_links?: boolean;
 }
 
 interface CrawlResponse {
@@ -12,10 +23,158 @@ interface CrawlResponse {
   message: string;
 }
 
+export interface Finding {
+  code: string;
+  severity: 'info' | 'warning' | 'error';
+  message: string;
+  path?: string;
+}
+
+export interface SEOData {
+  title_length: number;
+  meta_description: string;
+  meta_robots: string;
+  canonicals: string[] | null;
+  open_graph: Record<string, string>;
+  twitter_card: Record<string, string>;
+  hreflang: { lang: string; url: string }[] | null;
+  findings: Finding[] | null;
+}
+
+export interface Heading {
+  level: number;
+  text: string;
+  children?: Heading[];
+}
+
+export interface StructuredEntity {
+  format: 'json-ld' | 'microdata' | 'rdfa';
+  types: string[];
+  properties: Record<string, unknown>;
+}
+
+export interface CertificateInfo {
+  subject: string;
+  issuer: string;
+  dns_names: string[] | null;
+  not_before: string;
+  not_after: string;
+}
+
+export interface TLSInfo {
+  version: string;
+  cipher_suite: string;
+  server_name: string;
+  hostname_match: boolean;
+  days_until_expiry: number;
+  certificates: CertificateInfo[] | null;
+  findings: Finding[] | null;
+}
+
+export interface MixedContent {
+  url: string;
+  type: 'active' | 'passive';
+  element: string;
+  attribute: string;
+  path: string;
+}
+
+export interface HeaderVerdict {
+  name: string;
+  value: string;
+  present: boolean;
+  verdict: 'pass' | 'warn' | 'fail';
+  message: string;
+}
+
+export interface SecurityHeaders {
+  grade: string;
+  score: number;
+  headers: HeaderVerdict[];
+  csp?: Record<string, string[]>;
+}
+
+export interface FormInfo {
+  action: string;
+  method: string;
+  inputs: { name: string; type: string; autocomplete?: string }[] | null;
+  has_password: boolean;
+  has_csrf_token: boolean;
+  insecure_submit: boolean;
+  classification: 'login' | 'signup' | 'search' | 'newsletter' | 'password_reset' | 'other';
+  path: string;
+}
+
+export interface EncodingInfo {
+  encoding: string;
+  source: 'bom' | 'header' | 'meta' | 'detected';
+  header_charset?: string;
+  meta_charset?: string;
+  mismatch: boolean;
+}
+
+export type ResourceType = 'html' | 'pdf' | 'image' | 'json' | 'other';
+
+export interface ResourceInfo {
+  pdf?: { version: string; pages: number; title?: string; author?: string; encrypted: boolean };
+  image?: { format: string; width: number; height: number };
+  json?: { valid: boolean; root_type?: string; error?: string };
+}
+
+export interface Subresource {
+  url: string;
+  type: 'script' | 'stylesheet' | 'image' | 'font' | 'iframe' | 'media';
+  element: string;
+  third_party: boolean;
+  render_blocking: boolean;
+  checked: boolean;
+  size: number;
+  content_type?: string;
+}
+
+export interface ResourceInventory {
+  resources: Subresource[] | null;
+  counts: Record<string, number>;
+  third_party: number;
+  render_blocking: number;
+  total_size: number;
+  unknown_sizes: number;
+}
+
+export interface Readability {
+  word_count: number;
+  sentence_count: number;
+  syllable_count: number;
+  words_per_sentence: number;
+  syllables_per_word: number;
+  flesch_reading_ease: number;
+  flesch_kincaid_grade: number;
+  text_html_ratio: number;
+  keywords: { word: string; count: number; density: number }[];
+}
+
+export interface LanguageInfo {
+  language: string;
+  confidence: number;
+  html_lang: string;
+  content_language: string;
+  findings: Finding[];
+}
+
 export interface Result {
   id: number;
   crawl_request_id: number;
+  page_url_id: number;
+  url: string;
+  media_type: string;
+  size: number;
+  truncated: boolean;
+  resource_type: ResourceType;
+  archive_record_id: string;
+  resource: ResourceInfo | null;
+  depth: number;
   html_version: string;
+  encoding: EncodingInfo | null;
   title: string;
   h1_count: number;
   h2_count: number;
@@ -27,10 +186,139 @@ export interface Result {
   external_links: number;
   broken_links: number;
   has_login_form: boolean;
+  forms: FormInfo[] | null;
+  robots_skipped_links: string[] | null;
+  final_url: string;
+  status_code: number;
+  redirect_chain: RedirectHop[] | null;
+  redirect_chain_too_long: boolean;
+  redirect_loop: boolean;
+  seo: SEOData | null;
+  accessibility_findings: Finding[] | null;
+  outline: { headings: Heading[] | null; findings: Finding[] | null } | null;
+  structured_data: { entities: StructuredEntity[] | null; findings: Finding[] | null } | null;
+  resources: ResourceInventory | null;
+  tls: TLSInfo | null;
+  mixed_content: MixedContent[] | null;
+  security_grade: string;
+  security_headers: SecurityHeaders | null;
+  response_headers: Record<string, string> | null;
+  word_count: number;
+  readability: Readability | null;
+  main_text?: string;
+  language: string;
+  language_confidence: number;
+  language_mismatch: boolean;
+  language_info: LanguageInfo | null;
+  simhash: string;
   processing_time: number;
   created_at: string;
 }
 
+export interface RedirectHop {
+  url: string;
+  status_code: number;
+  location: string;
+}
+
+export interface CrawlLink {
+  id: number;
+  crawl_result_id: number;
+  href: string;
+  url: string;
+  anchor_text: string;
+  rel: string;
+  internal: boolean;
+  checked: boolean;
+  broken: boolean;
+  status_code: number;
+  error_type: string;
+  error: string;
+  redirect_url: string;
+  redirect_chain: RedirectHop[] | null;
+  redirect_chain_too_long: boolean;
+  redirect_loop: boolean;
+}
+
+export interface PageURL {
+  id: number;
+  url: string;
+  result_count: number;
+  last_crawled_at: string | null;
+  created_at: string;
+}
+
+export interface DiffLine {
+  op: '+' | '-';
+  text: string;
+}
+
+export interface URLDiff {
+  page_url_id: number;
+  url: string;
+  from: { result_id: number; final_url: string; crawled_at: string };
+  to: { result_id: number; final_url: string; crawled_at: string };
+  changed: boolean;
+  title: { from: string; to: string } | null;
+  headings: { added: string[]; removed: string[] };
+  links: { added: string[]; removed: string[]; newly_broken: string[]; fixed: string[] };
+  headers: { name: string; from: string; to: string }[];
+  content: { added: number; removed: number; lines: DiffLine[]; truncated: boolean };
+}
+
+export interface DuplicatePage {
+  result_id: number;
+  page_url_id: number;
+  url: string;
+  title: string;
+  word_count: number;
+  crawled_at: string;
+  similarity: number;
+}
+
+export interface DuplicatesReport {
+  threshold: number;
+  max_distance: number;
+  compared: number;
+  clusters: { size: number; pages: DuplicatePage[] }[];
+}
+
+interface DuplicatesResponse {
+  data: DuplicatesReport;
+  message: string;
+}
+
+interface URLsResponse {
+  data: PageURL[];
+  pagination: {
+    currentPage: number;
+    pageSize: number;
+    totalItems: number;
+    totalPages: number;
+    hasNext: boolean;
+    hasPrev: boolean;
+  };
+  message: string;
+}
+
+interface DiffResponse {
+  data: URLDiff;
+  message: string;
+}
+
+interface LinksResponse {
+  data: CrawlLink[];
+  pagination: {
+    currentPage: number;
+    pageSize: number;
+    totalItems: number;
+    totalPages: number;
+    hasNext: boolean;
+    hasPrev: boolean;
+  };
+  message: string;
+}
+
 interface ResultsResponse {
   data: Result[];
   pagination: {
@@ -116,8 +404,25 @@ export const crawl = async (data: CrawlRequest, token: string): Promise<CrawlRes
   return response.json();
 };
 
-export const getResults = async (page: number = 1, size: number = 10, token: string): Promise<ResultsResponse> => {
-  const response = await fetch(`${API_BASE_URL}/results?page=${page}&pageSize=${size}`, {
+export interface ResultFilters {
+  language?: string;
+  language_mismatch?: boolean;
+}
+
+export const getResults = async (
+  page: number = 1,
+  size: number = 10,
+  token: string,
+  filters: ResultFilters = {}
+): Promise<ResultsResponse> => {
+  const params = new URLSearchParams({ page: String(page), pageSize: String(size) });
+  if (filters.language) {
+    params.set('language', filters.language);
+  }
+  if (filters.language_mismatch !== undefined) {
+    params.set('language_mismatch', String(filters.language_mismatch));
+  }
+  const response = await fetch(`${API_BASE_URL}/results?${params.toString()}`, {
     method: 'GET',
     headers: {
       'Content-Type': 'application/json',
@@ -132,4 +437,110 @@ export const getResults = async (page: number = 1, size: number = 10, token: str
   }
 
   return response.json();
-};
\ No newline at end of file
+};
+
+export const getResultLinks = async (
+  resultId: number,
+  status: string,
+  token: string,
+  page: number = 1,
+  size: number = 100
+): Promise<LinksResponse> => {
+  const params = new URLSearchParams({ page: String(page), pageSize: String(size) });
+  if (status) {
+    params.set('status', status);
+  }
+  const response = await fetch(`${API_BASE_URL}/results/${resultId}/links?${params.toString()}`, {
+    method: 'GET',
+    headers: {
+      'Content-Type': 'application/json',
+      Authorization: `Bearer ${token}`,
+    },
+    credentials: 'include',
+  });
+
+  if (!response.ok) {
+    const errorData = await response.json().catch(() => ({}));
+    throw new Error(errorData.error || 'Failed to fetch links', { cause: { status: response.status } });
+  }
+
+  return response.json();
+};
+
+export const downloadResultArchive = async (resultId: number, token: string): Promise<Blob> => {
+  const response = await fetch(`${API_BASE_URL}/results/${resultId}/warc`, {
+    method: 'GET',
+    headers: {
+      Authorization: `Bearer ${token}`,
+    },
+    credentials: 'include',
+  });
+
+  if (!response.ok) {
+    const errorData = await response.json().catch(() => ({}));
+    throw new Error(errorData.error || 'Failed to download archive', { cause: { status: response.status } });
+  }
+
+  return response.blob();
+};
+
+export const getURLs = async (page: number = 1, size: number = 10, token: string): Promise<URLsResponse> => {
+  const response = await fetch(`${API_BASE_URL}/urls?page=${page}&pageSize=${size}`, {
+    method: 'GET',
+    headers: {
+      'Content-Type': 'application/json',
+      Authorization: `Bearer ${token}`,
+    },
+    credentials: 'include',
+  });
+
+  if (!response.ok) {
+    const errorData = await response.json().catch(() => ({}));
+    throw new Error(errorData.error || 'Failed to fetch URLs', { cause: { status: response.status } });
+  }
+
+  return response.json();
+};
+
+export const getURLDiff = async (urlId: number, token: string, from?: number, to?: number): Promise<DiffResponse> => {
+  const params = new URLSearchParams();
+  if (from !== undefined && to !== undefined) {
+    params.set('from', String(from));
+    params.set('to', String(to));
+  }
+  const query = params.toString();
+  const response = await fetch(`${API_BASE_URL}/urls/${urlId}/diff${query ? `?${query}` : ''}`, {
+    method: 'GET',
+    headers: {
+      'Content-Type': 'application/json',
+      Authorization: `Bearer ${token}`,
+    },
+    credentials: 'include',
+  });
+
+  if (!response.ok) {
+    const errorData = await response.json().catch(() => ({}));
+    throw new Error(errorData.error || 'Failed to fetch diff', { cause: { status: response.status } });
+  }
+
+  return response.json();
+};
+
+export const getDuplicates = async (token: string, threshold?: number): Promise<DuplicatesResponse> => {
+  const query = threshold !== undefined ? `?threshold=${threshold}` : '';
+  const response = await fetch(`${API_BASE_URL}/duplicates${query}`, {
+    method: 'GET',
+    headers: {
+      'Content-Type': 'application/json',
+      Authorization: `Bearer ${token}`,
+    },
+    credentials: 'include',
+  });
+
+  if (!response.ok) {
+    const errorData = await response.json().catch(() => ({}));
+    throw new Error(errorData.error || 'Failed to fetch duplicates', { cause: { status: response.status } });
+  }
+
+  return response.json();
+};
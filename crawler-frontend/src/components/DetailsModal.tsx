@@ -1,9 +1,9 @@
-import React from 'react';
+import React, { useEffect, useState } from 'react';
 import styled from 'styled-components';
 import { Chart as ChartJS, ArcElement, Tooltip, Legend } from 'chart.js';
 import { Doughnut } from 'react-chartjs-2';
 import { theme } from '../theme';
-import { Result } from '../api/crawler';
+import { CrawlLink, getResultLinks, Result } from '../api/crawler';
 import Button from './general/Button';
 import { SectionTitle, Spacer } from './Layout';
 
@@ -65,21 +65,21 @@ const NoLinksMessage = styled.p`
   text-align: center;
 `;
 
-interface BrokenLink {
-  url: string;
-  status: number;
-}
-
-interface ExtendedResult extends Result {
-  broken_links_details?: BrokenLink[]; // Hypothetical field for broken links
-}
-
 interface DetailsModalProps {
-  result: ExtendedResult;
+  result: Result;
   onClose: () => void;
 }
 
 const DetailsModal: React.FC<DetailsModalProps> = ({ result, onClose }) => {
+  const [brokenLinks, setBrokenLinks] = useState<CrawlLink[]>([]);
+
+  useEffect(() => {
+    const token = localStorage.getItem('access_token') || '';
+    getResultLinks(result.id, 'broken', token)
+      .then((response) => setBrokenLinks(response.data))
+      .catch(() => setBrokenLinks([]));
+  }, [result.id]);
+
   const chartData = {
     labels: ['Internal Links', 'External Links'],
     datasets: [
@@ -114,11 +114,11 @@ const DetailsModal: React.FC<DetailsModalProps> = ({ result, onClose }) => {
           <Doughnut data={chartData} options={options} />
         </ChartContainer>
         <SectionTitle>Broken Links</SectionTitle>
-        {result.broken_links_details && result.broken_links_details.length > 0 ? (
+        {brokenLinks.length > 0 ? (
           <BrokenLinksList>
-            {result.broken_links_details.map((link, index) => (
-              <BrokenLinkItem key={index}>
-                {link.url} (Status: {link.status})
+            {brokenLinks.map((link) => (
+              <BrokenLinkItem key={link.id}>
+                {link.url} ({link.status_code ? `Status: ${link.status_code}` : link.error})
               </BrokenLinkItem>
             ))}
           </BrokenLinksList>
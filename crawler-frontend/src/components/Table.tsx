@@ -1,10 +1,18 @@
 import React, { useState } from 'react';
 import { Box, Table, TableBody, TableCell, TableHead, TableRow, Typography } from '@mui/material';
-import { Result } from '../api/crawler';
+import { Result, ResourceType } from '../api/crawler';
 import Button from './general/Button';
 import DetailsModal from './DetailsModal';
 import { Spacer } from './Layout';
 
+const resourceLabels: Record<ResourceType, string> = {
+  html: 'HTML',
+  pdf: 'PDF',
+  image: 'Image',
+  json: 'JSON',
+  other: 'Other',
+};
+
 interface TableProps {
   results: Result[];
   onPageChange: (page: number) => void;
@@ -26,6 +34,7 @@ const ResultsTable: React.FC<TableProps> = ({ results, onPageChange, page, pageS
         <TableHead>
           <TableRow>
             <TableCell>Title</TableCell>
+            <TableCell>Type</TableCell>
             <TableCell>HTML Version</TableCell>
             <TableCell sx={{ textAlign: 'right' }}>Internal Links</TableCell>
             <TableCell sx={{ textAlign: 'right' }}>External Links</TableCell>
@@ -34,16 +43,21 @@ const ResultsTable: React.FC<TableProps> = ({ results, onPageChange, page, pageS
           </TableRow>
         </TableHead>
         <TableBody>
-          {results.map((result) => (
-            <TableRow key={result.id} onClick={() => setSelectedResult(result)} sx={{ cursor: 'pointer' }}>
-              <TableCell>{result.title}</TableCell>
-              <TableCell>{result.html_version}</TableCell>
-              <TableCell sx={{ textAlign: 'right' }}>{result.internal_links}</TableCell>
-              <TableCell sx={{ textAlign: 'right' }}>{result.external_links}</TableCell>
-              <TableCell sx={{ textAlign: 'right' }}>{result.broken_links}</TableCell>
-              <TableCell>{new Date(result.created_at).toLocaleString()}</TableCell>
-            </TableRow>
-          ))}
+          {results.map((result) => {
+            // Results stored before resource types were recorded are all HTML
+            const resourceType = result.resource_type || 'html';
+            return (
+              <TableRow key={result.id} onClick={() => setSelectedResult(result)} sx={{ cursor: 'pointer' }}>
+                <TableCell>{result.title || result.url}</TableCell>
+                <TableCell title={result.media_type}>{resourceLabels[resourceType]}</TableCell>
+                <TableCell>{resourceType === 'html' ? result.html_version : '—'}</TableCell>
+                <TableCell sx={{ textAlign: 'right' }}>{result.internal_links}</TableCell>
+                <TableCell sx={{ textAlign: 'right' }}>{result.external_links}</TableCell>
+                <TableCell sx={{ textAlign: 'right' }}>{result.broken_links}</TableCell>
+                <TableCell>{new Date(result.created_at).toLocaleString()}</TableCell>
+              </TableRow>
+            );
+          })}
         </TableBody>
       </Table>
       <Spacer size="md" />